@@ -0,0 +1,369 @@
+package lsfunctions
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// maxLinkHops bounds how many symbolic links are followed inside an archive.
+const maxLinkHops = 40
+
+// archiveKind returns the format of an archive from its file name:
+// "tar", "tgz" or "zip". It returns "" for any other name.
+func archiveKind(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".tar"):
+		return "tar"
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "tgz"
+	case strings.HasSuffix(name, ".zip"):
+		return "zip"
+	}
+	return ""
+}
+
+// isArchive reports whether a file is an archive that the Archive flag lets
+// the Lister browse like a directory.
+func (l *Lister) isArchive(name string, info fs.FileInfo) bool {
+	return l.Flags.Archive && info.Mode().IsRegular() && archiveKind(name) != ""
+}
+
+// archiveNode is a single member of an archive.
+type archiveNode struct {
+	info     fs.FileInfo
+	target   string
+	children []string
+}
+
+// archiveTree is the table of contents of an archive, keyed by the cleaned
+// path of each member. The root of the archive is ".".
+type archiveTree struct {
+	nodes map[string]*archiveNode
+}
+
+// add records a member of the archive, creating any parent directories the
+// archive does not list itself.
+func (t *archiveTree) add(name string, info fs.FileInfo, target string) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return
+	}
+	if node, ok := t.nodes[name]; ok {
+		node.info, node.target = info, target
+		return
+	}
+	t.nodes[name] = &archiveNode{info: info, target: target}
+	for {
+		dir := path.Dir(name)
+		parent, ok := t.nodes[dir]
+		if !ok {
+			parent = &archiveNode{info: archiveDirInfo{name: path.Base(dir), modTime: info.ModTime()}}
+			t.nodes[dir] = parent
+		}
+		parent.children = append(parent.children, path.Base(name))
+		if ok || dir == "." {
+			return
+		}
+		name = dir
+	}
+}
+
+// lookup returns the member at name, following symbolic links when follow is set.
+func (t *archiveTree) lookup(name string, follow bool) (*archiveNode, error) {
+	for hops := 0; ; hops++ {
+		node, ok := t.nodes[name]
+		if !ok {
+			return nil, fs.ErrNotExist
+		}
+		if !follow || node.info.Mode()&fs.ModeSymlink == 0 {
+			return node, nil
+		}
+		if hops == maxLinkHops {
+			return nil, syscall.ELOOP
+		}
+		if strings.HasPrefix(node.target, "/") {
+			name = path.Clean(node.target[1:])
+		} else {
+			name = path.Join(path.Dir(name), node.target)
+		}
+		if name == "" || name == "/" || strings.HasPrefix(name, "../") || name == ".." {
+			return nil, fs.ErrNotExist
+		}
+	}
+}
+
+// readArchive reads the table of contents of the archive f. A zip archive
+// is read in place when f can be read at any offset, as an *os.File can.
+func readArchive(f fs.File, kind string) (*archiveTree, error) {
+	var r io.Reader = f
+	tree := &archiveTree{nodes: map[string]*archiveNode{
+		".": {info: archiveDirInfo{name: "."}},
+	}}
+	switch kind {
+	case "tgz":
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+		fallthrough
+	case "tar":
+		tr := tar.NewReader(r)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				return tree, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			tree.add(hdr.Name, hdr.FileInfo(), hdr.Linkname)
+		}
+	case "zip":
+		ra, size, err := zipSource(f)
+		if err != nil {
+			return nil, err
+		}
+		zr, err := zip.NewReader(ra, size)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			var target string
+			if f.Mode()&fs.ModeSymlink != 0 {
+				target, err = readZipLink(f)
+				if err != nil {
+					return nil, err
+				}
+			}
+			tree.add(f.Name, f.FileInfo(), target)
+		}
+		return tree, nil
+	}
+	return nil, fmt.Errorf("unknown archive format %q", kind)
+}
+
+// zipSource returns f and its size for a zip reader to seek through. A file
+// that cannot be read at any offset is read into memory first.
+func zipSource(f fs.File) (io.ReaderAt, int64, error) {
+	if ra, ok := f.(io.ReaderAt); ok {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, 0, err
+		}
+		return ra, info.Size(), nil
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(data), int64(len(data)), nil
+}
+
+// readZipLink returns the target of a symbolic link stored in a zip archive,
+// which is kept as the content of the member.
+func readZipLink(f *zip.File) (string, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	target, err := io.ReadAll(rc)
+	return string(target), err
+}
+
+// archiveDirInfo describes a directory that an archive implies but does not list.
+type archiveDirInfo struct {
+	name    string
+	modTime time.Time
+}
+
+func (d archiveDirInfo) Name() string       { return d.name }
+func (d archiveDirInfo) Size() int64        { return 0 }
+func (d archiveDirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0o755 }
+func (d archiveDirInfo) ModTime() time.Time { return d.modTime }
+func (d archiveDirInfo) IsDir() bool        { return true }
+func (d archiveDirInfo) Sys() any           { return nil }
+
+// archiveFS lists the contents of archives found on an underlying file system
+// as if they were directories. A path such as "dist/app.tar.gz/bin/tool" is
+// split at the archive, whose table of contents is read once and kept.
+type archiveFS struct {
+	base fileSystem
+
+	mu    sync.Mutex
+	trees map[string]*archiveEntry
+}
+
+// archiveEntry is the table of contents of an archive of an archiveFS, read
+// by the first goroutine that needs it while the others wait.
+type archiveEntry struct {
+	once sync.Once
+	tree *archiveTree
+	err  error
+}
+
+func newArchiveFS(base fileSystem) *archiveFS {
+	return &archiveFS{base: base, trees: make(map[string]*archiveEntry)}
+}
+
+func (a *archiveFS) Lstat(name string) (fs.FileInfo, error) {
+	tree, inner, err := a.split(name, false)
+	if tree == nil || err != nil {
+		if err != nil {
+			return nil, err
+		}
+		return a.base.Lstat(name)
+	}
+	node, err := tree.lookup(inner, false)
+	if err != nil {
+		return nil, &fs.PathError{Op: "lstat", Path: name, Err: err}
+	}
+	return node.info, nil
+}
+
+func (a *archiveFS) Stat(name string) (fs.FileInfo, error) {
+	tree, inner, err := a.split(name, false)
+	if tree == nil || err != nil {
+		if err != nil {
+			return nil, err
+		}
+		return a.base.Stat(name)
+	}
+	node, err := tree.lookup(inner, true)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return node.info, nil
+}
+
+func (a *archiveFS) ReadLink(name string) (string, error) {
+	tree, inner, err := a.split(name, false)
+	if tree == nil || err != nil {
+		if err != nil {
+			return "", err
+		}
+		return a.base.ReadLink(name)
+	}
+	node, err := tree.lookup(inner, false)
+	if err != nil {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: err}
+	}
+	return node.target, nil
+}
+
+// ReadDir lists a directory inside an archive, or the root of the archive
+// when name is the archive itself.
+func (a *archiveFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	tree, inner, err := a.split(name, true)
+	if tree == nil || err != nil {
+		if err != nil {
+			return nil, err
+		}
+		return a.base.ReadDir(name)
+	}
+	node, err := tree.lookup(inner, true)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	if !node.info.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: syscall.ENOTDIR}
+	}
+	entries := make([]fs.DirEntry, 0, len(node.children))
+	for _, child := range node.children {
+		entries = append(entries, fs.FileInfoToDirEntry(tree.nodes[path.Join(inner, child)].info))
+	}
+	return entries, nil
+}
+
+func (a *archiveFS) HasExtendedAttributes(name string) (bool, error) {
+	if tree, _, err := a.split(name, false); tree != nil || err != nil {
+		return false, nil
+	}
+	return a.base.HasExtendedAttributes(name)
+}
+
+func (a *archiveFS) BirthTime(name string) (time.Time, bool) {
+	if tree, _, err := a.split(name, false); tree != nil || err != nil {
+		return time.Time{}, false
+	}
+	return a.base.BirthTime(name)
+}
+
+func (a *archiveFS) Open(name string) (fs.File, error) {
+	return a.base.Open(name)
+}
+
+// split finds the first archive among the components of name and returns its
+// table of contents along with the rest of the path inside it. With whole,
+// name may be the archive itself, which then stands for its root directory.
+// It returns a nil tree when name is not inside an archive.
+func (a *archiveFS) split(name string, whole bool) (*archiveTree, string, error) {
+	for i := 0; i <= len(name); i++ {
+		if i < len(name) && name[i] != '/' {
+			continue
+		}
+		if i == len(name) && !whole {
+			break
+		}
+		prefix := name[:i]
+		if archiveKind(prefix) == "" {
+			continue
+		}
+		info, err := a.base.Lstat(prefix)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		tree, err := a.tree(prefix)
+		if err != nil {
+			return nil, "", &fs.PathError{Op: "open", Path: prefix, Err: err}
+		}
+		inner := "."
+		if i < len(name) {
+			inner = strings.TrimPrefix(path.Clean("/"+name[i+1:]), "/")
+			if inner == "" {
+				inner = "."
+			}
+		}
+		return tree, inner, nil
+	}
+	return nil, "", nil
+}
+
+// tree returns the table of contents of the archive at name, reading it the
+// first time it is needed. Other archives can be read meanwhile.
+func (a *archiveFS) tree(name string) (*archiveTree, error) {
+	a.mu.Lock()
+	entry, ok := a.trees[name]
+	if !ok {
+		entry = &archiveEntry{}
+		a.trees[name] = entry
+	}
+	a.mu.Unlock()
+	entry.once.Do(func() {
+		entry.tree, entry.err = a.readTree(name)
+	})
+	return entry.tree, entry.err
+}
+
+// readTree reads the table of contents of the archive at name.
+func (a *archiveFS) readTree(name string) (*archiveTree, error) {
+	f, err := a.base.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readArchive(f, archiveKind(name))
+}
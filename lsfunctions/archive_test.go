@@ -0,0 +1,99 @@
+package lsfunctions
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// archiveFixture returns an in-memory file system holding the same small
+// tree stored as a tar and as a zip archive.
+func archiveFixture(t *testing.T) fstest.MapFS {
+	t.Helper()
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+	headers := []*tar.Header{
+		{Name: "src/a.txt", Mode: 0o644, Size: 2, Uname: "alice", Gname: "staff", Typeflag: tar.TypeReg},
+		{Name: "src/sub/b.txt", Mode: 0o600, Size: 0, Typeflag: tar.TypeReg},
+		{Name: "src/link", Mode: 0o777, Linkname: "a.txt", Typeflag: tar.TypeSymlink},
+	}
+	for _, hdr := range headers {
+		hdr.ModTime = time.Date(2020, 6, 15, 12, 0, 0, 0, time.UTC)
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(make([]byte, hdr.Size))
+	}
+	tw.Close()
+
+	var zipBuf bytes.Buffer
+	zw := zip.NewWriter(&zipBuf)
+	for _, hdr := range headers[:2] {
+		fh := &zip.FileHeader{Name: hdr.Name, Modified: hdr.ModTime}
+		fh.SetMode(hdr.FileInfo().Mode())
+		w, err := zw.CreateHeader(fh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(make([]byte, hdr.Size))
+	}
+	zw.Close()
+
+	return fstest.MapFS{
+		"dist/app.tar": {Data: tarBuf.Bytes(), Mode: 0o644},
+		"dist/app.zip": {Data: zipBuf.Bytes(), Mode: 0o644},
+	}
+}
+
+func Test_archiveKind(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want string
+	}{
+		{name: "tar", args: "app.tar", want: "tar"},
+		{name: "gzipped tar", args: "app.TAR.GZ", want: "tgz"},
+		{name: "tgz", args: "app.tgz", want: "tgz"},
+		{name: "zip", args: "app.zip", want: "zip"},
+		{name: "gzip only", args: "app.gz", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := archiveKind(tt.args); got != tt.want {
+				t.Errorf("archiveKind() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLister_ListArchive(t *testing.T) {
+	fsys := archiveFixture(t)
+	tests := []struct {
+		name  string
+		flags Flags
+		paths []string
+		want  string
+	}{
+		{name: "tar recursive", flags: Flags{Archive: true, OnePerLine: true, Recursive: true}, paths: []string{"dist/app.tar"}, want: "dist/app.tar:\nsrc\n\ndist/app.tar/src:\na.txt\nlink\nsub\n\ndist/app.tar/src/sub:\nb.txt\n"},
+		{name: "zip directory", flags: Flags{Archive: true, OnePerLine: true}, paths: []string{"dist/app.zip/src"}, want: "a.txt\nsub\n"},
+		{name: "recursive into archives", flags: Flags{Archive: true, OnePerLine: true, Recursive: true}, paths: []string{"dist"}, want: "dist:\napp.tar\napp.zip\n\ndist/app.tar:\nsrc\n\ndist/app.tar/src:\na.txt\nlink\nsub\n\ndist/app.tar/src/sub:\nb.txt\n\ndist/app.zip:\nsrc\n\ndist/app.zip/src:\na.txt\nsub\n\ndist/app.zip/src/sub:\nb.txt\n"},
+		{name: "without the flag", flags: Flags{OnePerLine: true}, paths: []string{"dist/app.tar"}, want: "dist/app.tar\n"},
+		{name: "tar long", flags: Flags{Archive: true, Long: true}, paths: []string{"dist/app.tar/src/a.txt", "dist/app.tar/src/link"}, want: "-rw-r--r-- 1 alice staff 2 Jun 15  2020 dist/app.tar/src/a.txt\nlrwxrwxrwx 1     0     0 0 Jun 15  2020 dist/app.tar/src/link -> a.txt\n"},
+		{name: "zip long", flags: Flags{Archive: true, Long: true}, paths: []string{"dist/app.zip/src"}, want: "total 0\n-rw-r--r-- 1 ? ? 2 Jun 15  2020 a.txt\ndrwxr-xr-x 1 ? ? 0 Jun 15  2020 sub\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			l := &Lister{Flags: tt.flags, Stdout: stdout, Stderr: &bytes.Buffer{}, FS: fsys, Resolver: fakeResolver{}}
+			if err := l.List(tt.paths); err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if got := stripColor(stdout.String()); got != tt.want {
+				t.Errorf("List() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
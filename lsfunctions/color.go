@@ -1,110 +1,101 @@
 package lsfunctions
 
 import (
+	"os"
 	"strings"
 )
 
-// Define color codes
+// Escape sequences of the built-in colour scheme, kept for the tests.
 const (
-	reset            = "\033[0m"
-	turqoise         = "\033[1;38;2;42;161;179m"
-	orangeBackground = "\033[48;2;192;28;20m"
-	boldBlue         = "\033[1;38;5;01;34m"
-	yellow           = "\033[38;2;162;115;76m"
-	boldYellow       = "\033[1;38;2;162;115;76m"
-	yellowBackground = "\033[48;2;162;115;76m"
-	blackBack        = "\033[40m"
-	blackText        = "\033[30m"
-	red              = "\033[1;31m"
-	cyan             = "\033[1;36m"
-	green            = "\033[1;38;2;39;169;105m"
-	greenBackground  = "\033[42m"
-	magentaBold      = "\033[1;35m"
-	pink             = "\033[1;38;2;162;71;186m"
-	socket           = "\033[1;38;2;163;71;181m"
+	reset    = "\033[0m"
+	boldBlue = "\033[1;38;5;01;34m"
 )
 
-// colorName applies color codes to different file types and symbolic links.
+// colorName applies the Lister's colour scheme to the name of an entry.
 // Returns the colorized name of the file or symbolic link.
-// If the entry is a symbolic link and the isTarget flag is true, the link target is also colorized.
-func colorName(entry Entry, isTarget bool) Entry {
-	// Color map for different file types
-	colors := map[string]string{
-		"video":          pink,
-		"world-writable": magentaBold,
-		"pipe":           yellow + blackBack,
-		"socket":         socket,
-		"sticky":         greenBackground + blackText,
-		"setuid":         orangeBackground,
-		"setgid":         yellowBackground + blackText,
-		"dir":            boldBlue,
-		"dev":            boldYellow + blackBack,
-		"archive":        red,
-		"audio":          "\x1b[1;96m", // Light Cyan
-		"image":          "\x1b[1;35m", // Magenta
-		"crd":            "\x1b[1;38;5;8m",
-		"css":            cyan,
-		"exec":           green,
+// isTarget tells that the entry is the target of a symbolic link, shown
+// after the arrow of the long format.
+// Names are left as they are when the Lister does not colour its output.
+func (l *Lister) colorName(entry Entry, isTarget bool) Entry {
+	if !l.useColor() {
+		return entry
 	}
-
-	// if strings.Contains(entry.Path, "/usr/bin") ||
-	// strings.Contains(entry.Path, "/bin") ||
-	// strings.Contains(entry.Path, "/dev") {
-
-	// if entry.IsBrokenLink {
-	// 	entry.Name = addColorAndPadding(red+blackBack, entry.Name, reset)
-	// 	return entry
-	// }
-	// Handle target links that are symbolic links
-	if isTarget {
-		if strings.Split(entry.Mode, "-")[0] == "lrwx" {
-			entry.Name = addColorAndPadding(boldYellow+blackBack, entry.Name, reset)
-			return entry
+	colors := l.colors()
+	if isTarget && entry.IsBrokenLink {
+		// The missing file a broken link points to.
+		if code := colors.types["mi"]; code != "" {
+			entry.Name = addColorAndPadding(colors.sequence(code), entry.Name, colors.reset())
 		}
+		return entry
 	}
-	// Handle symbolic links
-	if (entry.Mode[0] == 'l' || entry.Mode[0] == 'L') && !isTarget {
-		entry.Name = addColorAndPadding(turqoise, entry.Name, reset)
+	kind := getFileType(entry)
+	if kind == "ln" && !isTarget && colors.linkAsTarget && !entry.IsBrokenLink && entry.TargetInfo.Mode != "" {
+		target := l.colorName(Entry{Name: entry.Name, RawName: entry.RawName, Mode: entry.TargetInfo.Mode}, true)
+		entry.Name = target.Name
 		return entry
 	}
-
-	// Color code based on file type
-	entry, fileType := getFileType(entry)
-	if color, exists := colors[fileType]; exists {
-		entry.Name = addColorAndPadding(color, entry.Name, reset)
+	if kind == "ln" && entry.IsBrokenLink && colors.types["or"] != "" {
+		kind = "or"
+	}
+	// Suffixes are matched on the name itself, whatever its quoting. An
+	// entry made without RawName, as by hand, is matched on Name.
+	name := entry.RawName
+	if name == "" {
+		name = entry.Name
+	}
+	if code := colors.code(kind, entry.Mode, name, entry.LinkCount); code != "" {
+		entry.Name = addColorAndPadding(colors.sequence(code), entry.Name, colors.reset())
 	}
-
 	return entry
 }
 
+// useColor reports whether the Lister colours names, as decided once for
+// the Lister by detectColor.
+func (l *Lister) useColor() bool {
+	return l.settings().color
+}
+
+// detectColor reports whether names are to be coloured. With the default
+// ColorAuto, names are coloured when Stdout is a terminal; the NO_COLOR
+// environment variable turns this off and CLICOLOR_FORCE turns it on
+// whatever the output, NO_COLOR taking precedence.
+func (l *Lister) detectColor() bool {
+	switch l.Flags.Color {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	return IsTerminal(l.Stdout)
+}
+
 // addColorAndPadding adds color codes and padding to the file or symbolic link name.
 // The color and reset codes are applied to the name using ANSI escape sequences.
-// The padding is added to the end of the name to ensure consistent formatting.
+// The spaces padding the name are kept outside the escape sequences, where
+// they stay in place and are not coloured.
 // It returns the colored and padded name.
 func addColorAndPadding(color, name, reset string) string {
-	originalsize := len(name)
-	name = strings.TrimSpace(name)
-	padding := originalsize - len(name)
-	name = color + name + reset
-	name = name + strings.Repeat(" ", padding)
-	return name
+	trimmed := strings.TrimLeft(name, " ")
+	leading := name[:len(name)-len(trimmed)]
+	trimmed = strings.TrimRight(trimmed, " ")
+	trailing := name[len(leading)+len(trimmed):]
+	return leading + color + trimmed + reset + trailing
 }
 
-// This function resolves the target of symbolic links and applies color formatting to the link target.
-// It takes the path to the parent directory and the symbolic link path as input,
-// and returns the colored link target.
-func colorLinkTarget(path, s string) string {
-	newEntry, err := getLinkTargetType(path, s)
-	if err != nil {
-		return s
-		// if strings.Contains(err.Error(), "target not found") {
-		// 	newEntry.IsBrokenLink = true
-		// 	// entry.TargetInfo = TargetInfo{Name: linkTarget, Mode: newEntry.Mode, IsBrokenLink: true}
-		// } else {
-		// 	return s
-		// }
+// colorLinkTarget returns the target of the symbolic link e as the long
+// format writes it after the arrow: quoted like the names are, coloured like
+// the file it leads to, or with the "mi" colour when that file is missing,
+// and followed by the file's indicator.
+func (l *Lister) colorLinkTarget(e Entry) string {
+	target := Entry{Name: l.quoteFile(e.LinkTarget), RawName: e.LinkTarget, Mode: e.TargetInfo.Mode, Path: e.TargetInfo.Path, IsBrokenLink: e.IsBrokenLink}
+	if target.Mode == "" && !target.IsBrokenLink {
+		return target.Name
 	}
-	colorEntry := colorName(newEntry, true)
-
-	return colorEntry.Name
+	return l.colorName(target, true).Name + getIndicator(target.Mode, l.indicatorStyle())
 }
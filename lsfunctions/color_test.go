@@ -1,6 +1,7 @@
 package lsfunctions
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
 )
@@ -15,12 +16,146 @@ func Test_colorName(t *testing.T) {
 		want Entry
 	}{
 		{name: "test 1", args: args{entry: Entry{Name: "test.txt", Mode: "-rw-r--r--"}}, want: Entry{Name: "test.txt", Mode: "-rw-r--r--"}},
+		{name: "test 2", args: args{entry: Entry{Name: "src", Mode: "drwxr-xr-x"}}, want: Entry{Name: boldBlue + "src" + reset, Mode: "drwxr-xr-x"}},
+		{name: "test 3", args: args{entry: Entry{Name: "'a b.ZIP'", RawName: "a b.ZIP", Mode: "-rw-r--r--"}}, want: Entry{Name: "\033[1;31m'a b.ZIP'" + reset, RawName: "a b.ZIP", Mode: "-rw-r--r--"}},
+		{name: "test 4", args: args{entry: Entry{Name: "run.zip", Mode: "-rwxr-xr-x"}}, want: Entry{Name: "\033[1;38;2;39;169;105mrun.zip" + reset, Mode: "-rwxr-xr-x"}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := colorName(tt.args.entry, false); !reflect.DeepEqual(got, tt.want) {
+			if got := (&Lister{Flags: Flags{Color: ColorAlways}}).colorName(tt.args.entry, false); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("colorName() = %q, want %q", got.Name, tt.want.Name)
 			}
 		})
 	}
 }
+
+func TestLister_colorName_scheme(t *testing.T) {
+	colors, err := ParseLSColors("di=01;34:ow=34;42:ex=01;32:su=37;41:*.tar=01;31:*.TAR.gz=01;35:rs=0:lc=\\e[:rc=m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := &Lister{Flags: Flags{Color: ColorAlways}, Colors: colors}
+	tests := []struct {
+		name  string
+		entry Entry
+		want  string
+	}{
+		{name: "test 1", entry: Entry{Name: "src", Mode: "drwxr-xr-x"}, want: "\033[01;34msrc\033[0m"},
+		{name: "test 2", entry: Entry{Name: "tmp", Mode: "drwxrwxrwt"}, want: "\033[34;42mtmp\033[0m"},
+		{name: "test 3", entry: Entry{Name: "sudo", Mode: "-rwsr-xr-x"}, want: "\033[37;41msudo\033[0m"},
+		{name: "test 4", entry: Entry{Name: "a.tar", Mode: "-rw-r--r--"}, want: "\033[01;31ma.tar\033[0m"},
+		{name: "test 5", entry: Entry{Name: "a.tar.gz", Mode: "-rw-r--r--"}, want: "\033[01;35ma.tar.gz\033[0m"},
+		{name: "test 6", entry: Entry{Name: "a.zip", Mode: "-rw-r--r--"}, want: "a.zip"},
+		{name: "test 7", entry: Entry{Name: "fifo", Mode: "prw-r--r--"}, want: "\033[38;2;162;115;76;40mfifo\033[0m"},
+		{name: "test 8", entry: Entry{Name: "x.tar'", RawName: "x.tar'", Mode: "-rw-r--r--"}, want: "x.tar'"},
+		{name: "test 9", entry: Entry{Name: `'x.tar'\'''`, RawName: "x.tar'", Mode: "-rw-r--r--"}, want: `'x.tar'\'''`},
+		{name: "test 10", entry: Entry{Name: "'a b.tar'", RawName: "a b.tar", Mode: "-rw-r--r--"}, want: "\033[01;31m'a b.tar'\033[0m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.colorName(tt.entry, false).Name; got != tt.want {
+				t.Errorf("colorName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLister_useColor(t *testing.T) {
+	tests := []struct {
+		name       string
+		color      ColorWhen
+		noColor    string
+		forceColor string
+		want       bool
+	}{
+		{name: "test 1", color: ColorAuto, want: false},
+		{name: "test 2", color: ColorAlways, noColor: "1", want: true},
+		{name: "test 3", color: ColorNever, forceColor: "1", want: false},
+		{name: "test 4", color: ColorAuto, forceColor: "1", want: true},
+		{name: "test 5", color: ColorAuto, forceColor: "0", want: false},
+		{name: "test 6", color: ColorAuto, noColor: "1", forceColor: "1", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tt.noColor)
+			t.Setenv("CLICOLOR_FORCE", tt.forceColor)
+			l := &Lister{Flags: Flags{Color: tt.color}, Stdout: &bytes.Buffer{}}
+			if got := l.useColor(); got != tt.want {
+				t.Errorf("useColor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLister_useColor_once(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("CLICOLOR_FORCE", "1")
+	l := &Lister{Stdout: &bytes.Buffer{}}
+	if !l.useColor() {
+		t.Fatalf("useColor() = false, want true")
+	}
+	// The environment is read once for the Lister.
+	t.Setenv("CLICOLOR_FORCE", "0")
+	if !l.useColor() {
+		t.Errorf("useColor() = false after the environment changed, want true")
+	}
+}
+
+func TestLister_colorLinkTarget(t *testing.T) {
+	colors, err := ParseLSColors("di=01;34:ln=01;36:or=40;31;01:mi=01;05;37;41:ex=01;32:rs=0:lc=\\e[:rc=m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := Entry{Mode: "lrwxrwxrwx", LinkTarget: "../cur", TargetInfo: TargetInfo{Name: "../cur", Mode: "drwxr-xr-x", Type: "di"}}
+	broken := Entry{Mode: "lrwxrwxrwx", LinkTarget: "../gone", IsBrokenLink: true, TargetInfo: TargetInfo{Name: "../gone", IsBrokenLink: true}}
+	tests := []struct {
+		name  string
+		flags Flags
+		entry Entry
+		want  string
+	}{
+		{name: "test 1", flags: Flags{Color: ColorAlways}, entry: dir, want: "\033[01;34m../cur\033[0m"},
+		{name: "test 2", flags: Flags{Color: ColorAlways, Indicator: IndicatorClassify}, entry: dir, want: "\033[01;34m../cur\033[0m/"},
+		{name: "test 3", flags: Flags{Color: ColorAlways}, entry: broken, want: "\033[01;05;37;41m../gone\033[0m"},
+		{name: "test 4", flags: Flags{Color: ColorNever, Indicator: IndicatorClassify}, entry: broken, want: "../gone"},
+		{name: "test 5", flags: Flags{Color: ColorAlways}, entry: Entry{Mode: "lrwxrwxrwx", LinkTarget: "a.txt"}, want: "a.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Lister{Flags: tt.flags, Colors: colors}
+			if got := l.colorLinkTarget(tt.entry); got != tt.want {
+				t.Errorf("colorLinkTarget() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLister_colorName_links(t *testing.T) {
+	tests := []struct {
+		name   string
+		colors string
+		entry  Entry
+		want   string
+	}{
+		{name: "test 1", colors: "ln=01;36:or=40;31;01", entry: Entry{Name: "ok", Mode: "lrwxrwxrwx"}, want: "\033[01;36mok\033[0m"},
+		{name: "test 2", colors: "ln=01;36:or=40;31;01", entry: Entry{Name: "stale", Mode: "lrwxrwxrwx", IsBrokenLink: true}, want: "\033[40;31;01mstale\033[0m"},
+		{name: "test 3", colors: "ln=target:ex=01;32", entry: Entry{Name: "run", Mode: "lrwxrwxrwx", TargetInfo: TargetInfo{Mode: "-rwxr-xr-x", Type: "fi"}}, want: "\033[01;32mrun\033[0m"},
+		{name: "test 4", colors: "ln=target:or=40;31;01", entry: Entry{Name: "stale", Mode: "lrwxrwxrwx", IsBrokenLink: true}, want: "\033[40;31;01mstale\033[0m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			colors, err := ParseLSColors(tt.colors + ":rs=0:lc=\\e[:rc=m")
+			if err != nil {
+				t.Fatal(err)
+			}
+			l := &Lister{Flags: Flags{Color: ColorAlways}, Colors: colors}
+			got := l.colorName(tt.entry, false)
+			if got.Name != tt.want {
+				t.Errorf("colorName() = %q, want %q", got.Name, tt.want)
+			}
+			if got.Mode != tt.entry.Mode {
+				t.Errorf("colorName() mode = %q, want %q", got.Mode, tt.entry.Mode)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package lsfunctions
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// dircolorsKeywords maps the keywords of a dircolors database to the keys of LS_COLORS.
+var dircolorsKeywords = map[string]string{
+	"NORMAL":                "no",
+	"NORM":                  "no",
+	"FILE":                  "fi",
+	"RESET":                 "rs",
+	"DIR":                   "di",
+	"LNK":                   "ln",
+	"LINK":                  "ln",
+	"SYMLINK":               "ln",
+	"ORPHAN":                "or",
+	"MISSING":               "mi",
+	"FIFO":                  "pi",
+	"PIPE":                  "pi",
+	"SOCK":                  "so",
+	"DOOR":                  "do",
+	"BLK":                   "bd",
+	"BLOCK":                 "bd",
+	"CHR":                   "cd",
+	"CHAR":                  "cd",
+	"EXEC":                  "ex",
+	"LEFT":                  "lc",
+	"LEFTCODE":              "lc",
+	"RIGHT":                 "rc",
+	"RIGHTCODE":             "rc",
+	"END":                   "ec",
+	"ENDCODE":               "ec",
+	"SUID":                  "su",
+	"SETUID":                "su",
+	"SGID":                  "sg",
+	"SETGID":                "sg",
+	"STICKY":                "st",
+	"OTHER_WRITABLE":        "ow",
+	"OWR":                   "ow",
+	"STICKY_OTHER_WRITABLE": "tw",
+	"OWT":                   "tw",
+	"CAPABILITY":            "ca",
+	"MULTIHARDLINK":         "mh",
+	"CLRTOEOL":              "cl",
+}
+
+// ParseDircolors reads a colour scheme from a database in the format of
+// dircolors(1), such as the output of "dircolors --print-database":
+//
+//	# comment
+//	TERM xterm*
+//	DIR 01;34
+//	.tar 01;31
+//
+// Entries that follow a block of TERM or COLORTERM lines only apply when
+// one of their patterns matches term or colorterm, the values of the
+// environment variables of the same name.
+func ParseDircolors(r io.Reader, term, colorterm string) (*ColorScheme, error) {
+	const (
+		global   = iota // no TERM line seen yet
+		termNo          // in or after a block that does not match
+		termSure        // in a block that matches
+		termYes         // after a block that matches
+	)
+	c := newColorScheme()
+	state := global
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		keyword, value, ok := dircolorsLine(scanner.Text())
+		if !ok {
+			continue
+		}
+		if value == "" {
+			return nil, fmt.Errorf("line %d: missing value for %s", line, keyword)
+		}
+		upper := strings.ToUpper(keyword)
+		if upper == "TERM" || upper == "COLORTERM" {
+			subject := term
+			if upper == "COLORTERM" {
+				subject = colorterm
+			}
+			if matched, _ := path.Match(value, subject); matched {
+				state = termSure
+			} else if state != termSure {
+				state = termNo
+			}
+			continue
+		}
+		if state == termSure {
+			state = termYes
+		}
+		if state == termNo {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(keyword, "."):
+			if err := c.set("*"+keyword, unescapeDircolors(value)); err != nil {
+				return nil, err
+			}
+		case strings.HasPrefix(keyword, "*"):
+			if err := c.set(keyword, unescapeDircolors(value)); err != nil {
+				return nil, err
+			}
+		case upper == "OPTIONS" || upper == "COLOR" || upper == "EIGHTBIT":
+			// Options of older versions of ls, ignored like dircolors does.
+		default:
+			key, known := dircolorsKeywords[upper]
+			if !known {
+				return nil, fmt.Errorf("line %d: unrecognized keyword %s", line, keyword)
+			}
+			if err := c.set(key, unescapeDircolors(value)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// dircolorsLine splits a line of a dircolors database into its keyword and
+// value. It reports false for blank lines and comments.
+func dircolorsLine(line string) (keyword, value string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' {
+		return "", "", false
+	}
+	keyword = line
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		keyword, value = line[:i], line[i+1:]
+	}
+	if i := strings.IndexByte(value, '#'); i >= 0 {
+		value = value[:i]
+	}
+	return keyword, strings.TrimSpace(value), true
+}
+
+// unescapeDircolors turns the escapes of a value of a dircolors database
+// into the characters they stand for, as LS_COLORS does.
+func unescapeDircolors(value string) string {
+	s, _, err := scanColorString(value, 0, false)
+	if err != nil {
+		return value
+	}
+	return s
+}
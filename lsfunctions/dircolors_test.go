@@ -0,0 +1,51 @@
+package lsfunctions
+
+import (
+	"strings"
+	"testing"
+)
+
+const testDircolors = `# Configuration file for dircolors
+COLOR tty
+TERM linux
+TERM xterm*
+DIR 01;34 # directories
+LINK target
+.tar 01;31
+*README 04
+
+TERM vt100
+EXEC 01;32
+`
+
+func TestParseDircolors(t *testing.T) {
+	tests := []struct {
+		name    string
+		db      string
+		term    string
+		want    string
+		wantErr bool
+	}{
+		{name: "test 1", db: testDircolors, term: "xterm-256color",
+			want: "lc=\\e[:rc=m:rs=0:di=01;34:ln=target:pi=38;2;162;115;76;40:so=1;38;2;163;71;181:" +
+				"bd=1;38;2;162;115;76;40:cd=1;38;2;162;115;76;40:or=40;31;01:ex=1;38;2;39;169;105:su=48;2;192;28;20:" +
+				"sg=48;2;162;115;76;30:st=42;30:*.tar=01;31:*README=04:"},
+		{name: "test 2", db: testDircolors, term: "vt100",
+			want: "lc=\\e[:rc=m:rs=0:di=1;38;5;01;34:ln=1;38;2;42;161;179:pi=38;2;162;115;76;40:so=1;38;2;163;71;181:" +
+				"bd=1;38;2;162;115;76;40:cd=1;38;2;162;115;76;40:or=40;31;01:ex=01;32:su=48;2;192;28;20:" +
+				"sg=48;2;162;115;76;30:st=42;30:"},
+		{name: "test 3", db: "BOGUS 01", wantErr: true},
+		{name: "test 4", db: "DIR", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDircolors(strings.NewReader(tt.db), tt.term, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDircolors() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("ParseDircolors() = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
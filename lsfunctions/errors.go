@@ -0,0 +1,151 @@
+package lsfunctions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"syscall"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ListError is a problem met while listing a path, such as a missing file or
+// a directory that cannot be opened. It wraps the underlying error, so the
+// system error behind it can be checked with errors.Is, for instance against
+// fs.ErrNotExist, syscall.EACCES, syscall.ELOOP, syscall.ENOTDIR or
+// ErrAlreadyListed.
+type ListError struct {
+	// Op is what could not be done, as in "cannot access" or
+	// "cannot open directory". It is empty when Err says it all.
+	Op   string
+	Path string
+	Err  error
+	// Operand is set when Path was given on the command line. Like GNU ls,
+	// a problem with an operand is serious, while one with a file found
+	// inside a directory is minor.
+	Operand bool
+}
+
+// ErrAlreadyListed is the error of a directory a recursive listing meets
+// inside itself, as through a symbolic link followed with -L. It is not
+// listed again, and like GNU ls, the problem is serious.
+var ErrAlreadyListed = errors.New("not listing already-listed directory")
+
+func (e *ListError) Error() string {
+	if e.Op == "" {
+		return fmt.Sprintf("%s: %s", e.Path, e.Err)
+	}
+	return fmt.Sprintf("%s '%s': %s", e.Op, e.Path, errorText(e.Err))
+}
+
+func (e *ListError) Unwrap() error { return e.Err }
+
+// ExitStatus returns the exit status of ls for the error returned by
+// Lister.List: 0 when everything was listed, 1 for minor problems such as
+// a subdirectory that cannot be opened, and 2 for serious trouble such as
+// an operand that cannot be accessed or output that cannot be written.
+// A listing cancelled by Lister.ListContext gives 130, the status of a
+// program interrupted by Ctrl-C, whatever else went wrong.
+func ExitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		status := 0
+		for _, err := range joined.Unwrap() {
+			status = getMax(status, ExitStatus(err))
+		}
+		return status
+	}
+	if errors.Is(err, context.Canceled) {
+		return 130
+	}
+	var listErr *ListError
+	if errors.As(err, &listErr) && !listErr.Operand && !errors.Is(err, ErrAlreadyListed) {
+		return 1
+	}
+	return 2
+}
+
+// markOperand records whether the directory readDir failed on was given on
+// the command line. It leaves alone the errors about single entries that
+// come with a partial listing.
+func markOperand(err error, entries []FileDetails, operand bool) {
+	var listErr *ListError
+	if entries == nil && errors.As(err, &listErr) {
+		listErr.Operand = operand
+	}
+}
+
+// errorText describes the system error behind err the way strerror does,
+// as in "No such file or directory".
+func errorText(err error) string {
+	var errno syscall.Errno
+	var pathErr *fs.PathError
+	var text string
+	switch {
+	case errors.As(err, &errno):
+		text = errno.Error()
+	case errors.Is(err, fs.ErrNotExist):
+		return "No such file or directory"
+	case errors.Is(err, fs.ErrPermission):
+		return "Permission denied"
+	case errors.As(err, &pathErr):
+		text = pathErr.Err.Error()
+	default:
+		text = err.Error()
+	}
+	r, size := utf8.DecodeRuneInString(text)
+	return string(unicode.ToUpper(r)) + text[size:]
+}
+
+// listRun holds the state of one listing: the problems found so far and
+// whether anything was written yet, which decides if the next directory
+// is separated from what came before by a blank line.
+type listRun struct {
+	ctx     context.Context
+	stderr  io.Writer
+	errs    []error
+	started bool
+	// active holds the directories being listed by a recursive listing,
+	// the one being listed and its parents.
+	active map[fileID]bool
+}
+
+// report writes a problem to Stderr and remembers it for the exit status.
+// Several problems joined with errors.Join are reported one per line.
+func (r *listRun) report(err error) {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, err := range joined.Unwrap() {
+			r.report(err)
+		}
+		return
+	}
+	fmt.Fprintf(r.stderr, "ls: %v\n", err)
+	r.errs = append(r.errs, err)
+}
+
+// err returns every problem reported during the run, or nil, along with
+// the error of its context when the run was cancelled.
+func (r *listRun) err() error {
+	return errors.Join(append(r.errs, r.ctx.Err())...)
+}
+
+// enter records that a recursive listing entered the directory id at path.
+// It reports false, after reporting the problem, when the directory is
+// already being listed higher up, as when a symbolic link followed with -L
+// leads back to one of its parents. leave forgets the directory once it
+// and its subdirectories are listed.
+func (r *listRun) enter(path string, id fileID) (leave func(), ok bool) {
+	if r.active[id] {
+		r.report(&ListError{Path: path, Err: ErrAlreadyListed})
+		return func() {}, false
+	}
+	if r.active == nil {
+		r.active = make(map[fileID]bool)
+	}
+	r.active[id] = true
+	return func() { delete(r.active, id) }, true
+}
@@ -0,0 +1,117 @@
+package lsfunctions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"syscall"
+	"testing"
+	"testing/fstest"
+)
+
+// deniedFS refuses to read one of the directories of a MapFS.
+type deniedFS struct {
+	fstest.MapFS
+	dir string
+}
+
+func (d deniedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == d.dir {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrPermission}
+	}
+	return d.MapFS.ReadDir(name)
+}
+
+func TestLister_List_errors(t *testing.T) {
+	fsys := fstest.MapFS{
+		"top/a.txt":     {},
+		"top/sub/b.txt": {},
+	}
+	tests := []struct {
+		name       string
+		flags      Flags
+		denied     string
+		paths      []string
+		wantStdout string
+		wantStderr string
+		wantStatus int
+	}{
+		{name: "subdirectory", flags: Flags{OnePerLine: true, Recursive: true}, denied: "top/sub", paths: []string{"top"},
+			wantStdout: "top:\na.txt\nsub\n",
+			wantStderr: "ls: cannot open directory 'top/sub': Permission denied\n", wantStatus: 1},
+		{name: "operand", flags: Flags{OnePerLine: true}, denied: "top/sub", paths: []string{"top/sub", "top"},
+			wantStdout: "top:\na.txt\nsub\n",
+			wantStderr: "ls: cannot open directory 'top/sub': Permission denied\n", wantStatus: 2},
+		{name: "missing operand", flags: Flags{OnePerLine: true}, paths: []string{"nope", "top/a.txt"},
+			wantStdout: "top/a.txt\n",
+			wantStderr: "ls: cannot access 'nope': No such file or directory\n", wantStatus: 2},
+		{name: "json", flags: Flags{JSON: true, Recursive: true}, denied: "top/sub", paths: []string{"top/sub/b.txt", "top"},
+			wantStderr: "ls: cannot open directory 'top/sub': Permission denied\n", wantStatus: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			l := &Lister{Flags: tt.flags, Stdout: stdout, Stderr: stderr, Resolver: fakeResolver{}, FS: deniedFS{MapFS: fsys, dir: tt.denied}}
+			err := l.List(tt.paths)
+			if got := ExitStatus(err); got != tt.wantStatus {
+				t.Errorf("ExitStatus(List()) = %v, want %v (error %v)", got, tt.wantStatus, err)
+			}
+			if got := stdout.String(); tt.wantStdout != "" && got != tt.wantStdout {
+				t.Errorf("List() stdout = %q, want %q", got, tt.wantStdout)
+			}
+			if got := stderr.String(); got != tt.wantStderr {
+				t.Errorf("List() stderr = %q, want %q", got, tt.wantStderr)
+			}
+			if !errors.Is(err, fs.ErrPermission) && !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("List() error = %v, want it to wrap the cause", err)
+			}
+		})
+	}
+}
+
+func Test_errorText(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "test 1", err: &fs.PathError{Op: "lstat", Path: "x", Err: syscall.ENOENT}, want: "No such file or directory"},
+		{name: "test 2", err: &fs.PathError{Op: "open", Path: "x", Err: syscall.EACCES}, want: "Permission denied"},
+		{name: "test 3", err: syscall.ELOOP, want: "Too many levels of symbolic links"},
+		{name: "test 4", err: &fs.PathError{Op: "lstat", Path: "x/y", Err: syscall.ENOTDIR}, want: "Not a directory"},
+		{name: "test 5", err: fs.ErrNotExist, want: "No such file or directory"},
+		{name: "test 6", err: errors.New("bad archive"), want: "Bad archive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorText(tt.err); got != tt.want {
+				t.Errorf("errorText() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExitStatus(t *testing.T) {
+	minor := &ListError{Op: "cannot open directory", Path: "a/b", Err: syscall.EACCES}
+	serious := &ListError{Op: "cannot access", Path: "c", Err: syscall.ENOENT, Operand: true}
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "test 1", err: nil, want: 0},
+		{name: "test 2", err: minor, want: 1},
+		{name: "test 3", err: errors.Join(minor, serious), want: 2},
+		{name: "test 4", err: errors.New("write error"), want: 2},
+		{name: "test 5", err: &ListError{Path: "a/up", Err: ErrAlreadyListed}, want: 2},
+		{name: "test 6", err: errors.Join(serious, context.Canceled), want: 130},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitStatus(tt.err); got != tt.want {
+				t.Errorf("ExitStatus() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
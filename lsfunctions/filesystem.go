@@ -0,0 +1,125 @@
+package lsfunctions
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// ReadLinkFS is implemented by file systems that can read the target of a
+// symbolic link. Without it, link targets are not shown.
+type ReadLinkFS interface {
+	fs.FS
+	ReadLink(name string) (string, error)
+}
+
+// LstatFS is implemented by file systems that can describe a symbolic link
+// itself rather than the file it points to.
+type LstatFS interface {
+	fs.FS
+	Lstat(name string) (fs.FileInfo, error)
+}
+
+// fileSystem is the set of operations a Lister needs to list files.
+type fileSystem interface {
+	Lstat(name string) (fs.FileInfo, error)
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.DirEntry, error)
+	ReadLink(name string) (string, error)
+	HasExtendedAttributes(name string) (bool, error)
+	Open(name string) (fs.File, error)
+	BirthTime(name string) (time.Time, bool)
+}
+
+// osFS lists the files of the operating system.
+type osFS struct{}
+
+func (osFS) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(name) }
+func (osFS) Stat(name string) (fs.FileInfo, error)  { return os.Stat(name) }
+func (osFS) ReadLink(name string) (string, error)   { return os.Readlink(name) }
+func (osFS) Open(name string) (fs.File, error)      { return os.Open(name) }
+
+func (osFS) BirthTime(name string) (time.Time, bool) { return birthTime(name) }
+
+func (osFS) HasExtendedAttributes(name string) (bool, error) {
+	return hasExtendedAttributes(name)
+}
+
+// ReadDir returns the entries of a directory in the order the system gives them.
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	dir, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+	return dir.ReadDir(-1)
+}
+
+// ioFS lists the files of an fs.FS.
+// Paths are cleaned into the unrooted form fs.FS expects, so "./a", "/a"
+// and "a" all name the same file, and the parent of the root is the root.
+type ioFS struct {
+	fsys fs.FS
+}
+
+func (f ioFS) Lstat(name string) (fs.FileInfo, error) {
+	if lfs, ok := f.fsys.(LstatFS); ok {
+		return lfs.Lstat(f.path(name))
+	}
+	return fs.Stat(f.fsys, f.path(name))
+}
+
+func (f ioFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(f.fsys, f.path(name))
+}
+
+func (f ioFS) Open(name string) (fs.File, error) {
+	return f.fsys.Open(f.path(name))
+}
+
+func (f ioFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(f.fsys, f.path(name))
+}
+
+func (f ioFS) ReadLink(name string) (string, error) {
+	if rfs, ok := f.fsys.(ReadLinkFS); ok {
+		return rfs.ReadLink(f.path(name))
+	}
+	return "", &fs.PathError{Op: "readlink", Path: name, Err: errors.ErrUnsupported}
+}
+
+// HasExtendedAttributes always reports false, as fs.FS has no notion of them.
+func (f ioFS) HasExtendedAttributes(name string) (bool, error) {
+	return false, nil
+}
+
+// BirthTime always reports false, as fs.FileInfo does not carry a birth time.
+func (f ioFS) BirthTime(name string) (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// path converts a path as used by the Lister into a valid fs.FS path.
+func (f ioFS) path(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+// fsys returns the file system the Lister reads from.
+// With the Archive flag, archives on it can be listed like directories.
+func (l *Lister) fsys() fileSystem {
+	var base fileSystem = osFS{}
+	if l.FS != nil {
+		base = ioFS{fsys: l.FS}
+	}
+	if !l.Flags.Archive {
+		return base
+	}
+	l.archivesOnce.Do(func() { l.archives = newArchiveFS(base) })
+	return l.archives
+}
@@ -0,0 +1,68 @@
+package lsfunctions
+
+import (
+	"bytes"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// testFS is a small in-memory tree used instead of the ted/ fixture directory.
+var testFS = fstest.MapFS{
+	"docs/readme.md":    {Data: []byte("hello"), Mode: 0o644, ModTime: time.Date(2024, 11, 20, 6, 9, 0, 0, time.UTC)},
+	"docs/guide.txt":    {Data: []byte("guide"), Mode: 0o600, ModTime: time.Date(2024, 11, 20, 6, 9, 0, 0, time.UTC)},
+	"docs/old/notes.md": {Mode: 0o644},
+	"bin/run":           {Mode: 0o755},
+	"latest":            {Data: []byte("docs/readme.md"), Mode: fs.ModeSymlink | 0o777},
+}
+
+func Test_ioFS_path(t *testing.T) {
+	tests := []struct {
+		name string
+		args string
+		want string
+	}{
+		{name: "current directory", args: ".", want: "."},
+		{name: "dot slash", args: "./docs/old", want: "docs/old"},
+		{name: "rooted", args: "/docs", want: "docs"},
+		{name: "parent of root", args: "..", want: "."},
+		{name: "parent of directory", args: "docs/old/..", want: "docs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (ioFS{fsys: testFS}).path(tt.args); got != tt.want {
+				t.Errorf("ioFS.path() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLister_ListFS(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags Flags
+		paths []string
+		want  string
+	}{
+		{name: "recursive", flags: Flags{OnePerLine: true, Recursive: true}, paths: []string{"docs"}, want: "docs:\nguide.txt\nold\nreadme.md\n\ndocs/old:\nnotes.md\n"},
+		{name: "all", flags: Flags{OnePerLine: true, All: true}, paths: []string{"docs/old"}, want: ".\n..\nnotes.md\n"},
+		{name: "long symlink", flags: Flags{Long: true}, paths: []string{"latest"}, want: "lrwxrwxrwx 1 ? ? 14 ? latest -> docs/readme.md\n"},
+		{name: "classify", flags: Flags{Columns: true, Width: 80, Indicator: IndicatorClassify}, paths: []string{"."}, want: "bin/  docs/  latest@\n"},
+		{name: "classify recursive", flags: Flags{OnePerLine: true, Recursive: true, Indicator: IndicatorClassify}, paths: []string{"bin"}, want: "bin:\nrun*\n"},
+		{name: "long classify symlink", flags: Flags{Long: true, Indicator: IndicatorClassify}, paths: []string{"latest"}, want: "lrwxrwxrwx 1 ? ? 14 ? latest -> docs/readme.md\n"},
+		{name: "long slash", flags: Flags{Long: true, Indicator: IndicatorSlash}, paths: []string{"docs"}, want: "total 0\n-rw------- 1 ? ? 5 Nov 20  2024 guide.txt\ndr-xr-xr-x 1 ? ? 0            ? old/\n-rw-r--r-- 1 ? ? 5 Nov 20  2024 readme.md\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			l := &Lister{Flags: tt.flags, Stdout: stdout, Stderr: &bytes.Buffer{}, FS: testFS}
+			if err := l.List(tt.paths); err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if got := stripColor(stdout.String()); got != tt.want {
+				t.Errorf("List() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
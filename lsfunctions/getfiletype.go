@@ -1,92 +1,74 @@
 package lsfunctions
 
-import (
-	"fmt"
-	"os"
-	"strings"
-)
-
-// getFileType determines the file type based on the file mode and file extension.
-// Returns the entry with the file type.
-// If the file type cannot be determined, it returns other.
-func getFileType(entry Entry) (Entry, string) {
-	mod := entry.Mode
-	// Handel pipe files
-	if entry.Mode[0] == 'p' {
-		return entry, "pipe"
-	}
-	if entry.Mode[0] == 's' {
-		return entry, "socket"
+// getFileType determines the type of a file from its mode string.
+// Returns the LS_COLORS key of the type: "fi" for regular files, "di" for
+// directories, "ln" for symbolic links, "pi" for named pipes, "so" for
+// sockets, "bd" and "cd" for block and character devices and "do" for doors.
+func getFileType(entry Entry) string {
+	switch entry.Mode[0] {
+	case 'd':
+		return "di"
+	case 'l', 'L':
+		return "ln"
+	case 'p':
+		return "pi"
+	case 's':
+		return "so"
+	case 'b':
+		return "bd"
+	case 'c':
+		return "cd"
+	case 'D':
+		return "do"
 	}
-	// Handle setuid files
-	if entry.Mode[3] == 's' || entry.Mode[3] == 'S' {
-		return entry, "setuid"
+	return "fi"
+}
+
+// getIndicator returns the character appended to a name in style, from the
+// mode string of the file: "/" for directories, "@" for symbolic links, "|"
+// for named pipes, "=" for sockets, ">" for doors and, with the classify
+// style, "*" for executable regular files.
+func getIndicator(mode string, style IndicatorStyle) string {
+	if style == IndicatorNone || mode == "" {
+		return ""
 	}
-	// Handle setuid files
-	if entry.Mode[6] == 's' || entry.Mode[6] == 'S' {
-		return entry, "setgid"
+	kind := getFileType(Entry{Mode: mode})
+	if style == IndicatorSlash && kind != "di" {
+		return ""
 	}
-	// Handle directories
-	if entry.Mode[0] == 'd' {
-		if strings.HasSuffix(entry.Mode, "t") || strings.HasSuffix(entry.Mode, "T") {
-			return entry, "sticky"
+	switch kind {
+	case "di":
+		return "/"
+	case "ln":
+		return "@"
+	case "pi":
+		return "|"
+	case "so":
+		return "="
+	case "do":
+		return ">"
+	case "fi":
+		if style == IndicatorClassify && isExecutable(mode) {
+			return "*"
 		}
-		return entry, "dir"
-	}
-	// Handle device files
-	if entry.Mode[0] == 'b' || entry.Mode[0] == 'c' {
-		return entry, "dev"
 	}
+	return ""
+}
 
-	if strings.ContainsAny(mod, "x") {
-		return entry, "exec"
-	}
-	// if strings.Count(mod, "w") == 3 {
-	// 	return entry, "worldwritable"
-	// }
-	name := strings.TrimRight(strings.TrimLeft(strings.TrimSpace(entry.Name), "'"), "'")
-	tokens := strings.Split(strings.ToLower(name), ".")
-	ext := "." + tokens[len(tokens)-1]
-	switch ext {
-	case ".log":
-		return entry, "text"
-	case ".pdf":
-		return entry, "pdf"
-	case ".rar", ".zip", ".tar", ".gz", ".7z", "deb":
-		return entry, "archive"
-	case ".webm", ".mp4", ".mkv", ".avi", ".mov", ".flv", ".mpg", ".mpeg":
-		return entry, "video"
-	case ".mp3", ".wav", ".flac":
-		return entry, "audio"
-	case ".webp", ".jpg", ".jpeg", ".png", ".gif":
-		return entry, "image"
-	case ".crdownload":
-		return entry, "crd"
-	default:
-		return entry, "other"
-	}
+// indicatorStyle returns the indicator style of the Lister, as decided once
+// for the Lister by detectIndicatorStyle.
+func (l *Lister) indicatorStyle() IndicatorStyle {
+	return l.settings().indicator
 }
 
-// This function resolves the target of symbolic links by concatenating the parent directory path and the symbolic link path.
-// It takes the parent directory path and the symbolic link path as input,
-// and returns the resolved target path.
-func getLinkTargetType(path, s string) (Entry, error) {
-	absPath := resolveRelativePath(path, s)
-	if strings.HasPrefix(s, "../share") && !strings.HasPrefix(absPath, "/usr") {
-		absPath = "/usr" + absPath
-	}
-	info, err := os.Lstat(absPath)
-	var newEntry Entry
-	if os.IsNotExist(err) {
-		return newEntry, fmt.Errorf("target not found: %s", s)
-	} else if err != nil {
-		return newEntry, fmt.Errorf("error getting target info: %s", err.Error())
-	} else {
-		permissions, err := formatPermissionsWithACL(absPath, info.Mode())
-		if err != nil {
-			return newEntry, fmt.Errorf("cannot format permissions: %s", err.Error())
+// detectIndicatorStyle returns the indicator style of the flags, deciding
+// whether IndicatorClassifyAuto classifies names.
+func (l *Lister) detectIndicatorStyle() IndicatorStyle {
+	if l.Flags.Indicator == IndicatorClassifyAuto {
+		if IsTerminal(l.Stdout) {
+			return IndicatorClassify
 		}
-		newEntry = Entry{Name: s, Mode: permissions, Path: absPath}
+		return IndicatorNone
 	}
-	return newEntry, nil
+	return l.Flags.Indicator
 }
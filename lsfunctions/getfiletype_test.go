@@ -1,6 +1,9 @@
 package lsfunctions
 
-import "testing"
+import (
+	"bytes"
+	"testing"
+)
 
 func Test_getFileType(t *testing.T) {
 	type args struct {
@@ -11,13 +14,62 @@ func Test_getFileType(t *testing.T) {
 		args args
 		want string
 	}{
-		{name: "test 1", args: args{entry: Entry{Name: "test.pdf", Mode: "-rw-r--r--"}}, want: "pdf"},
+		{name: "test 1", args: args{entry: Entry{Name: "test.pdf", Mode: "-rw-r--r--"}}, want: "fi"},
+		{name: "test 2", args: args{entry: Entry{Name: "bin", Mode: "lrwxrwxrwx"}}, want: "ln"},
+		{name: "test 3", args: args{entry: Entry{Name: "sda", Mode: "brw-rw----"}}, want: "bd"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if _, got := getFileType(tt.args.entry); got != tt.want {
+			if got := getFileType(tt.args.entry); got != tt.want {
 				t.Errorf("getFileType() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_getIndicator(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  string
+		style IndicatorStyle
+		want  string
+	}{
+		{name: "test 1", mode: "drwxr-xr-x", style: IndicatorNone, want: ""},
+		{name: "test 2", mode: "drwxr-xr-x", style: IndicatorSlash, want: "/"},
+		{name: "test 3", mode: "lrwxrwxrwx", style: IndicatorSlash, want: ""},
+		{name: "test 4", mode: "lrwxrwxrwx", style: IndicatorFileType, want: "@"},
+		{name: "test 5", mode: "prw-r--r--", style: IndicatorFileType, want: "|"},
+		{name: "test 6", mode: "srwxr-xr-x", style: IndicatorClassify, want: "="},
+		{name: "test 7", mode: "-rwxr-xr-x", style: IndicatorFileType, want: ""},
+		{name: "test 8", mode: "-rwxr-xr-x", style: IndicatorClassify, want: "*"},
+		{name: "test 9", mode: "-rw-r--r--", style: IndicatorClassify, want: ""},
+		{name: "test 10", mode: "crw-rw-rw-", style: IndicatorClassify, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getIndicator(tt.mode, tt.style); got != tt.want {
+				t.Errorf("getIndicator() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLister_indicatorStyle(t *testing.T) {
+	tests := []struct {
+		name string
+		args IndicatorStyle
+		want IndicatorStyle
+	}{
+		{name: "test 1", args: IndicatorClassifyAuto, want: IndicatorNone},
+		{name: "test 2", args: IndicatorClassify, want: IndicatorClassify},
+		{name: "test 3", args: IndicatorSlash, want: IndicatorSlash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Lister{Flags: Flags{Indicator: tt.args}, Stdout: &bytes.Buffer{}}
+			if got := l.indicatorStyle(); got != tt.want {
+				t.Errorf("indicatorStyle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
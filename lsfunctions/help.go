@@ -0,0 +1,117 @@
+package lsfunctions
+
+import (
+	"fmt"
+	"io"
+)
+
+// Version is the version of the program, printed by --version.
+const Version = "1.0"
+
+// usage is the text printed by --help.
+const usage = `Usage: ls [OPTION]... [FILE]...
+List information about the FILEs (the current directory by default).
+Sort entries alphabetically if none of -tSUXv nor --sort is specified.
+
+Mandatory arguments to long options are mandatory for short options too.
+  -a, --all                  do not ignore entries starting with .
+      --archive              list the contents of .tar, .tar.gz, .tgz and .zip
+                               files as if they were directories
+      --author               with -l, print the author of each file
+  -b, --escape               print C-style escapes for nongraphic characters
+      --block-size=SIZE      with -l, scale sizes by SIZE when printing them;
+                               e.g., '--block-size=M'
+  -c                         with -l: show ctime; with -t: sort by ctime;
+                               otherwise: sort by ctime, newest first
+  -C                         list entries by columns
+  -f                         list all entries in directory order, and
+                               turn off -l, -s and --color
+  -F, --classify[=WHEN]      append indicator (one of */=>@|) to entries;
+                               WHEN can be 'always' (default if omitted),
+                               'auto', or 'never'
+      --file-type            likewise, except do not append '*'
+      --find-broken          list only symbolic links whose target is missing
+      --format=WORD          across -x, long -l, single-column -1,
+                               vertical -C, json
+      --full-time            like -l --time-style=full-iso
+  -g                         like -l, but do not list owner
+  -G, --no-group             in a long listing, don't print group names
+  -h, --human-readable       with -l, print sizes like 1K 234M 2G etc.
+      --si                   likewise, but use powers of 1000 not 1024
+  -H, --dereference-command-line
+                             follow symbolic links listed on the command line
+      --dereference-command-line-symlink-to-dir
+                             follow each command line symbolic link
+                               that points to a directory
+      --color[=WHEN]         colorize the output; WHEN can be 'always' (default
+                               if omitted), 'auto', or 'never'; without
+                               --color, names are coloured on a terminal
+                               unless NO_COLOR is set
+      --dircolors=FILE       read the colours of names from the dircolors
+                               database FILE instead of LS_COLORS
+  -i, --inode                print the index number of each file
+      --indicator-style=WORD
+                             append indicator with style WORD to entry names:
+                               none (default), slash (-p),
+                               file-type (--file-type), classify (-F)
+      --jobs=N               read directories and gather the details of
+                               their files on at most N goroutines besides
+                               the one writing the listing
+                               (default: one per CPU)
+  -l                         use a long listing format
+  -L, --dereference          when showing file information for a symbolic
+                               link, show information for the file the link
+                               references rather than for the link itself
+      --max-open-files=N     read at most N directories at once; no more
+                               than --jobs are read at once in any case
+  -n, --numeric-uid-gid      like -l, but list numeric user and group IDs
+  -N, --literal              print entry names without quoting
+      --ndjson               stream one JSON record per entry
+  -o                         like -l, but do not list group information
+  -p                         append / indicator to directories
+      --print-colors         print the colour of each kind of file and exit
+  -q, --hide-control-chars   print ? instead of nongraphic characters
+      --show-control-chars   show nongraphic characters as-is (the default,
+                               unless program is 'ls' and output is a terminal)
+  -Q, --quote-name           enclose entry names in double quotes
+      --quoting-style=WORD   use quoting style WORD for entry names:
+                               literal, locale, shell, shell-always,
+                               shell-escape, shell-escape-always, c, escape
+                               (overrides QUOTING_STYLE environment variable)
+  -r, --reverse              reverse order while sorting
+  -R, --recursive            list subdirectories recursively
+  -s, --size                 print the allocated size of each file, in blocks
+  -S                         sort by file size, largest first
+      --sort=WORD            sort by WORD instead of name: none (-U), size (-S),
+                               time (-t), version (-v), extension (-X)
+      --time=WORD            select which timestamp is shown and sorted on:
+                               atime (-u), ctime (-c), birth, mtime
+      --time-style=STYLE     time/date format with -l: full-iso, long-iso, iso,
+                               locale, or +FORMAT as for strftime
+  -t                         sort by time, newest first; see --time
+  -u                         with -l: show access time; with -t: sort by it;
+                               otherwise: sort by access time, newest first
+  -U                         do not sort; list entries in directory order
+  -v                         natural sort of (version) numbers within text
+  -w, --width=COLS           set output width to COLS.  0 means no limit
+  -x                         list entries by lines instead of by columns
+  -X                         sort alphabetically by entry extension
+  -1                         list one file per line
+      --help                 display this help and exit
+      --version              output version information and exit
+
+Exit status:
+ 0  if OK,
+ 1  if minor problems (e.g., cannot access subdirectory),
+ 2  if serious trouble (e.g., cannot access command-line argument).
+`
+
+// PrintUsage writes the --help text to w.
+func PrintUsage(w io.Writer) {
+	fmt.Fprint(w, usage)
+}
+
+// PrintVersion writes the --version text to w.
+func PrintVersion(w io.Writer) {
+	fmt.Fprintf(w, "ls (my-ls) %s\n", Version)
+}
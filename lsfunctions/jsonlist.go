@@ -0,0 +1,266 @@
+package lsfunctions
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"syscall"
+	"time"
+)
+
+// jsonEntry is the machine-readable form of a single entry.
+// Directories listed with -R carry their contents in Children.
+type jsonEntry struct {
+	Name        string      `json:"name"`
+	Path        string      `json:"path"`
+	Mode        uint32      `json:"mode"`
+	Permissions string      `json:"permissions"`
+	UID         uint32      `json:"uid"`
+	GID         uint32      `json:"gid"`
+	User        string      `json:"user,omitempty"`
+	Group       string      `json:"group,omitempty"`
+	Size        int64       `json:"size"`
+	Nlink       uint64      `json:"nlink"`
+	ModTime     string      `json:"mtime"`
+	Rdev        *jsonDevice `json:"rdev,omitempty"`
+	LinkTarget  string      `json:"link_target,omitempty"`
+	BrokenLink  *bool       `json:"broken_link,omitempty"`
+	Children    []jsonEntry `json:"children,omitempty"`
+}
+
+// jsonDevice holds the device numbers of block and character devices.
+type jsonDevice struct {
+	Major uint64 `json:"major"`
+	Minor uint64 `json:"minor"`
+}
+
+// DisplayJSON writes the listing of the given paths as a single JSON array.
+// Each path becomes one object; directories hold their entries in "children",
+// and with flags.Recursive the subdirectories are nested the same way.
+// Paths that cannot be accessed are reported on stderr and left out.
+func DisplayJSON(w io.Writer, paths []string, flags Flags) error {
+	l := NewLister(flags)
+	l.Stdout = w
+	return l.displayJSON(context.Background(), paths)
+}
+
+// displayJSON writes the listing of the given paths as a single JSON array.
+// Once ctx is done, no more directories are read and the array holds what
+// was read until then.
+func (l *Lister) displayJSON(ctx context.Context, paths []string) error {
+	run := l.newRun(ctx)
+	nodes := make([]jsonEntry, 0, len(paths))
+	for _, path := range paths {
+		info, err := l.statOperand(path)
+		if err != nil {
+			run.report(&ListError{Op: "cannot access", Path: path, Err: err, Operand: true})
+			continue
+		}
+		details, _ := l.handleNonDirectory(path, info)
+		node := l.newJSONEntry(details[0])
+		if l.isDirOperand(path, info) {
+			node.Children = l.jsonChildren(run, path, true)
+		} else if l.Flags.FindBroken && !details[0].IsBrokenLink {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+
+	enc := json.NewEncoder(l.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(nodes); err != nil {
+		return err
+	}
+	return run.err()
+}
+
+// jsonChildren reads the directory at path and converts its entries,
+// descending into subdirectories when the Recursive flag is set.
+// operand tells whether path was given on the command line.
+func (l *Lister) jsonChildren(run *listRun, path string, operand bool) []jsonEntry {
+	if run.ctx.Err() != nil {
+		return nil
+	}
+	if l.Flags.Recursive {
+		leave, ok := l.enterDir(run, path)
+		if !ok {
+			return nil
+		}
+		defer leave()
+	}
+	entries, err := l.readDir(path)
+	if err != nil {
+		markOperand(err, entries, operand)
+		run.report(err)
+		if entries == nil {
+			return nil
+		}
+	}
+	children := make([]jsonEntry, 0, len(entries))
+	for _, entry := range entries {
+		node := l.newJSONEntry(entry)
+		if l.Flags.Recursive && l.canDescend(entry) {
+			node.Children = l.jsonChildren(run, entry.Path, false)
+		}
+		if l.Flags.FindBroken && !entry.IsBrokenLink && len(node.Children) == 0 {
+			// Directories are kept only to hold the broken links found in them.
+			continue
+		}
+		children = append(children, node)
+	}
+	return children
+}
+
+// DisplayNDJSON streams the listing of the given paths as newline-delimited
+// JSON, one record per entry. Records are written as soon as a directory has
+// been read and sorted, and with flags.Recursive only the names of pending
+// subdirectories are kept in memory while their parents are walked.
+func DisplayNDJSON(w io.Writer, paths []string, flags Flags) error {
+	l := NewLister(flags)
+	l.Stdout = w
+	return l.displayNDJSON(context.Background(), paths)
+}
+
+// displayNDJSON streams the listing of the given paths as newline-delimited
+// JSON, until ctx is done.
+func (l *Lister) displayNDJSON(ctx context.Context, paths []string) error {
+	run := l.newRun(ctx)
+	bw := bufio.NewWriter(l.Stdout)
+	enc := json.NewEncoder(bw)
+	for _, path := range paths {
+		info, err := l.statOperand(path)
+		if err != nil {
+			if err := bw.Flush(); err != nil {
+				return err
+			}
+			run.report(&ListError{Op: "cannot access", Path: path, Err: err, Operand: true})
+			continue
+		}
+		if !l.isDirOperand(path, info) {
+			details, _ := l.handleNonDirectory(path, info)
+			if l.Flags.FindBroken && !details[0].IsBrokenLink {
+				continue
+			}
+			if err := enc.Encode(l.newJSONEntry(details[0])); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := l.streamDir(run, bw, enc, path, true); err != nil {
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return run.err()
+}
+
+// streamDir writes one record per entry of the directory at path and then
+// walks its subdirectories in the same order as -R does. Problems with the
+// files are reported through run; the returned error is a write failure.
+func (l *Lister) streamDir(run *listRun, bw *bufio.Writer, enc *json.Encoder, path string, operand bool) error {
+	if run.ctx.Err() != nil {
+		return nil
+	}
+	if l.Flags.Recursive {
+		leave, ok := l.enterDir(run, path)
+		if !ok {
+			return bw.Flush()
+		}
+		defer leave()
+	}
+	entries, err := l.readDir(path)
+	if err != nil {
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		markOperand(err, entries, operand)
+		run.report(err)
+		if entries == nil {
+			return nil
+		}
+	}
+	var subdirs []string
+	for _, entry := range entries {
+		if !l.Flags.FindBroken || entry.IsBrokenLink {
+			if err := enc.Encode(l.newJSONEntry(entry)); err != nil {
+				return err
+			}
+		}
+		if l.Flags.Recursive && l.canDescend(entry) {
+			subdirs = append(subdirs, entry.Path)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	for _, dir := range subdirs {
+		if err := l.streamDir(run, bw, enc, dir, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newJSONEntry collects the details of a single entry for JSON output.
+func (l *Lister) newJSONEntry(entry FileDetails) jsonEntry {
+	info := entry.Info
+	mode := info.Mode()
+	j := jsonEntry{
+		Name:        entry.Name,
+		Path:        entry.Path,
+		Mode:        unixMode(mode),
+		Permissions: formatPermissions(mode),
+		Size:        info.Size(),
+		ModTime:     info.ModTime().Format(time.RFC3339),
+		LinkTarget:  entry.LinkTarget,
+	}
+	if stat, ok := fileStat(info); ok {
+		j.UID = stat.Uid
+		j.GID = stat.Gid
+		j.Nlink = stat.Nlink
+		if mode&os.ModeDevice != 0 {
+			j.Rdev = &jsonDevice{Major: major(stat.Rdev), Minor: minor(stat.Rdev)}
+		}
+		j.User, j.Group = l.ownerNames(stat)
+	}
+	if mode&os.ModeSymlink != 0 {
+		broken := entry.IsBrokenLink
+		j.BrokenLink = &broken
+	}
+	return j
+}
+
+// unixMode converts Go's file mode bits into the st_mode encoding used by stat(2).
+func unixMode(mode os.FileMode) uint32 {
+	m := uint32(mode.Perm())
+	switch {
+	case mode&os.ModeDir != 0:
+		m |= syscall.S_IFDIR
+	case mode&os.ModeSymlink != 0:
+		m |= syscall.S_IFLNK
+	case mode&os.ModeNamedPipe != 0:
+		m |= syscall.S_IFIFO
+	case mode&os.ModeSocket != 0:
+		m |= syscall.S_IFSOCK
+	case mode&os.ModeCharDevice != 0:
+		m |= syscall.S_IFCHR
+	case mode&os.ModeDevice != 0:
+		m |= syscall.S_IFBLK
+	default:
+		m |= syscall.S_IFREG
+	}
+	if mode&os.ModeSetuid != 0 {
+		m |= syscall.S_ISUID
+	}
+	if mode&os.ModeSetgid != 0 {
+		m |= syscall.S_ISGID
+	}
+	if mode&os.ModeSticky != 0 {
+		m |= syscall.S_ISVTX
+	}
+	return m
+}
@@ -0,0 +1,74 @@
+package lsfunctions
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func Test_unixMode(t *testing.T) {
+	tests := []struct {
+		name string
+		mode os.FileMode
+		want uint32
+	}{
+		{name: "regular file", mode: 0o644, want: 0o100644},
+		{name: "directory", mode: os.ModeDir | 0o755, want: 0o40755},
+		{name: "symlink", mode: os.ModeSymlink | 0o777, want: 0o120777},
+		{name: "char device", mode: os.ModeDevice | os.ModeCharDevice | 0o666, want: 0o20666},
+		{name: "setuid", mode: os.ModeSetuid | 0o755, want: 0o104755},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unixMode(tt.mode); got != tt.want {
+				t.Errorf("unixMode() = %o, want %o", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisplayJSON(t *testing.T) {
+	w := &bytes.Buffer{}
+	if err := DisplayJSON(w, []string{"../ted"}, Flags{Recursive: true}); err != nil {
+		t.Fatalf("DisplayJSON() error = %v", err)
+	}
+	var got []jsonEntry
+	if err := json.Unmarshal(w.Bytes(), &got); err != nil {
+		t.Fatalf("DisplayJSON() wrote invalid JSON: %v", err)
+	}
+	if len(got) != 1 || len(got[0].Children) != 3 {
+		t.Fatalf("DisplayJSON() = %+v, want one directory with 3 children", got)
+	}
+	td := got[0].Children[2]
+	if td.Name != "td" || len(td.Children) != 1 || td.Children[0].Path != "../ted/td/onyango.txt" {
+		t.Errorf("DisplayJSON() nested directory = %+v", td)
+	}
+}
+
+func TestDisplayNDJSON(t *testing.T) {
+	w := &bytes.Buffer{}
+	if err := DisplayNDJSON(w, []string{"../ted"}, Flags{Recursive: true}); err != nil {
+		t.Fatalf("DisplayNDJSON() error = %v", err)
+	}
+	type record struct{ Path, Name string }
+	want := []record{
+		{Path: "../ted/kat.txt", Name: "kat.txt"},
+		{Path: "../ted/kkk.md", Name: "kkk.md"},
+		{Path: "../ted/td", Name: "td"},
+		{Path: "../ted/td/onyango.txt", Name: "onyango.txt"},
+	}
+	var got []record
+	dec := json.NewDecoder(w)
+	for dec.More() {
+		var entry jsonEntry
+		if err := dec.Decode(&entry); err != nil {
+			t.Fatalf("DisplayNDJSON() wrote invalid JSON: %v", err)
+		}
+		got = append(got, record{Path: entry.Path, Name: entry.Name})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DisplayNDJSON() records = %+v, want %+v", got, want)
+	}
+}
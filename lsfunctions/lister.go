@@ -0,0 +1,277 @@
+package lsfunctions
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// Lister lists files and directories with a fixed set of options.
+// Every line it produces goes through Stdout or Stderr and it keeps no
+// package-level state, so listings can be captured or run concurrently
+// by giving each goroutine its own Lister. Its fields are not to be changed
+// once it has listed something, as the settings it draws from them are kept.
+type Lister struct {
+	Flags  Flags
+	Stdout io.Writer
+	Stderr io.Writer
+	// Now returns the current time. It decides whether a timestamp is
+	// recent enough to be shown with its time of day.
+	Now func() time.Time
+	// Resolver turns numeric user and group IDs into names. NewLister
+	// sets a CachingResolver around the system's user database; a
+	// FileResolver reads the one of another root file system instead.
+	Resolver IDResolver
+	// FS is the file system to list. When nil, the operating system's
+	// files are listed. Symbolic links are only shown as such when FS
+	// also implements LstatFS and ReadLinkFS.
+	FS fs.FS
+	// Less, when set, replaces the sort order selected by the flags.
+	// It reports whether entry a is listed before entry b. As directories
+	// are read concurrently, it may be called from several goroutines.
+	Less func(a, b FileDetails) bool
+	// Colors is the colour scheme names are written in, such as the one
+	// given by ParseLSColors. When nil, the built-in scheme is used.
+	Colors *ColorScheme
+
+	// archives caches the contents of the archives read with the Archive flag.
+	archivesOnce sync.Once
+	archives     *archiveFS
+	// systemResolver is the resolver used when Resolver is nil.
+	resolverOnce   sync.Once
+	systemResolver IDResolver
+	// defaultColors is the colour scheme used when Colors is nil.
+	colorsOnce    sync.Once
+	defaultColors *ColorScheme
+	// output holds the output settings, see settings.
+	outputOnce sync.Once
+	output     outputSettings
+	// workers bounds the goroutines of the Lister, see pool.
+	poolOnce sync.Once
+	workers  pool
+}
+
+// NewLister returns a Lister for the given flags that writes to the
+// process's standard output and standard error.
+func NewLister(flags Flags) *Lister {
+	return &Lister{
+		Flags:    flags,
+		Stdout:   os.Stdout,
+		Stderr:   os.Stderr,
+		Now:      time.Now,
+		Resolver: NewCachingResolver(osResolver{}),
+	}
+}
+
+// List lists the given paths the way ls lists its operands.
+// Files are shown first as a single group, followed by each directory's
+// contents under a "path:" header when more than one operand is given or
+// when listing recursively. Problems are reported on Stderr as they are
+// found, and the returned error joins them as *ListError values; ExitStatus
+// turns it into the exit status of ls.
+func (l *Lister) List(paths []string) error {
+	return l.ListContext(context.Background(), paths)
+}
+
+// ListContext is like List, but stops when ctx is done, as when the user
+// interrupts ls. What was listed until then stays written, and the
+// returned error holds ctx.Err() along with the problems met.
+// Directories are read on several goroutines, ahead of their turn, while
+// the output keeps the order of List.
+func (l *Lister) ListContext(ctx context.Context, paths []string) error {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	// Sort paths alphabetically and case-insensitively
+	paths, _ = sortPaths(l.fsys(), paths)
+
+	if l.Flags.NDJSON {
+		return l.displayNDJSON(ctx, paths)
+	}
+	if l.Flags.JSON {
+		return l.displayJSON(ctx, paths)
+	}
+
+	run := l.newRun(ctx)
+	var files []FileDetails
+	var dirs []string
+	for _, path := range paths {
+		info, err := l.statOperand(path)
+		if err != nil {
+			run.report(&ListError{Op: "cannot access", Path: path, Err: err, Operand: true})
+			continue
+		}
+		if l.isDirOperand(path, info) {
+			dirs = append(dirs, path)
+			continue
+		}
+		file, _ := l.handleNonDirectory(path, info)
+		files = append(files, file...)
+	}
+
+	w := l.newWalker(ctx)
+	defer w.stop()
+	reads := w.read(dirs)
+	if len(files) > 0 {
+		l.display(l.sortEntries(files), false)
+		run.started = true
+	}
+	for _, r := range reads {
+		l.listDir(run, w, r, l.Flags.Recursive || len(paths) > 1, true)
+	}
+	return run.err()
+}
+
+// newRun starts a listing that reports its problems on the Lister's Stderr
+// and stops when ctx is done.
+func (l *Lister) newRun(ctx context.Context) *listRun {
+	return &listRun{stderr: l.Stderr, ctx: ctx}
+}
+
+// dereference returns which symbolic links the Lister follows. As in GNU
+// ls, the links to directories given on the command line are followed by
+// default, unless the long format or the classify indicators are used.
+func (l *Lister) dereference() Dereference {
+	if l.Flags.Dereference != DerefDefault {
+		return l.Flags.Dereference
+	}
+	if l.Flags.Long || l.indicatorStyle() == IndicatorClassify {
+		return DerefNever
+	}
+	return DerefSymlinkToDir
+}
+
+// statOperand returns the details of a path given on the command line:
+// those of the file it points to with -H and -L, and of the path itself
+// otherwise.
+func (l *Lister) statOperand(path string) (fs.FileInfo, error) {
+	switch l.dereference() {
+	case DerefCommandLine, DerefAlways:
+		return l.fsys().Stat(path)
+	}
+	return l.fsys().Lstat(path)
+}
+
+// isDirOperand reports whether an operand should be listed as a directory.
+// Symbolic links to directories are followed when the Lister dereferences them.
+func (l *Lister) isDirOperand(path string, info fs.FileInfo) bool {
+	if info.IsDir() || l.isArchive(path, info) {
+		return true
+	}
+	if info.Mode()&os.ModeSymlink == 0 || l.dereference() != DerefSymlinkToDir {
+		return false
+	}
+	target, err := l.fsys().Stat(path)
+	return err == nil && target.IsDir()
+}
+
+// canDescend reports whether a recursive listing should descend into entry.
+func (l *Lister) canDescend(entry FileDetails) bool {
+	if entry.Name == "." || entry.Name == ".." {
+		return false
+	}
+	return entry.Info.IsDir() || l.isArchive(entry.Path, entry.Info)
+}
+
+// enterDir records that a recursive listing entered the directory at path,
+// as listRun.enter does. Directories are told apart by their device and
+// inode numbers; those of file systems that have none are not checked.
+func (l *Lister) enterDir(run *listRun, path string) (leave func(), ok bool) {
+	id, ok := l.dirID(path)
+	if !ok {
+		return func() {}, true
+	}
+	return run.enter(path, id)
+}
+
+// dirID returns the device and inode numbers of the directory at path. It
+// reports false when they are not known.
+func (l *Lister) dirID(path string) (fileID, bool) {
+	info, err := l.fsys().Stat(path)
+	if err != nil {
+		return fileID{}, false
+	}
+	stat, ok := fileStat(info)
+	if !ok || stat.Ino == 0 {
+		return fileID{}, false
+	}
+	return fileID{dev: stat.Dev, ino: stat.Ino}, true
+}
+
+// display writes entries in the format selected by the flags, or only the
+// broken links among them with FindBroken.
+// total controls whether the long format, or the short one with -s, starts
+// with a "total" line.
+func (l *Lister) display(entries []FileDetails, total bool) {
+	if l.Flags.FindBroken {
+		entries = brokenLinks(entries)
+	}
+	if l.Flags.Long {
+		l.displayLongFormat(entries, total)
+	} else {
+		if total && l.Flags.Size {
+			l.writeTotal(entries)
+		}
+		l.displayShortList(entries)
+	}
+}
+
+// brokenLinks returns the entries that are broken symbolic links.
+func brokenLinks(entries []FileDetails) []FileDetails {
+	var broken []FileDetails
+	for _, entry := range entries {
+		if entry.IsBrokenLink {
+			broken = append(broken, entry)
+		}
+	}
+	return broken
+}
+
+// now returns the current time from the Lister's clock.
+func (l *Lister) now() time.Time {
+	if l.Now == nil {
+		return time.Now()
+	}
+	return l.Now()
+}
+
+// resolver returns the Lister's ID resolver, falling back to a cache of
+// the system one that lasts as long as the Lister.
+func (l *Lister) resolver() IDResolver {
+	if l.Resolver == nil {
+		l.resolverOnce.Do(func() { l.systemResolver = NewCachingResolver(osResolver{}) })
+		return l.systemResolver
+	}
+	return l.Resolver
+}
+
+// outputSettings are the settings of the output that depend on the
+// environment or on whether Stdout is a terminal.
+type outputSettings struct {
+	// color tells whether names are coloured.
+	color bool
+	// quoting is the style names are quoted in, and hideControl tells
+	// whether the nonprintable characters left are shown as "?".
+	quoting     QuotingStyle
+	hideControl bool
+	// indicator is the indicator style, with IndicatorClassifyAuto
+	// settled.
+	indicator IndicatorStyle
+}
+
+// settings returns the output settings of the Lister. They are worked out
+// once, the first time a name is written, rather than for every name.
+func (l *Lister) settings() *outputSettings {
+	l.outputOnce.Do(func() {
+		l.output = outputSettings{
+			color:       l.detectColor(),
+			quoting:     l.detectQuotingStyle(),
+			hideControl: l.detectHideControlChars(),
+			indicator:   l.detectIndicatorStyle(),
+		}
+	})
+	return &l.output
+}
@@ -0,0 +1,277 @@
+package lsfunctions
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// fakeResolver resolves every ID to a fixed name.
+type fakeResolver struct{ user, group string }
+
+func (r fakeResolver) LookupUser(uid uint32) (string, error)  { return r.user, nil }
+func (r fakeResolver) LookupGroup(gid uint32) (string, error) { return r.group, nil }
+
+func TestLister_List(t *testing.T) {
+	tests := []struct {
+		name       string
+		flags      Flags
+		paths      []string
+		wantStdout string
+		wantStderr string
+		wantErr    bool
+	}{
+		{name: "directory", flags: Flags{OnePerLine: true}, paths: []string{"../ted"}, wantStdout: "kat.txt\nkkk.md\ntd\n"},
+		{name: "colored directory", flags: Flags{OnePerLine: true, Color: ColorAlways}, paths: []string{"../ted"}, wantStdout: "kat.txt\nkkk.md\n" + boldBlue + "td" + reset + "\n"},
+		{name: "file and directory", flags: Flags{OnePerLine: true}, paths: []string{"../ted/td", "../ted/kat.txt"}, wantStdout: "../ted/kat.txt\n\n../ted/td:\nonyango.txt\n"},
+		{name: "recursive", flags: Flags{OnePerLine: true, Recursive: true}, paths: []string{"../ted"}, wantStdout: "../ted:\nkat.txt\nkkk.md\ntd\n\n../ted/td:\nonyango.txt\n"},
+		{name: "missing", paths: []string{"../nope"}, wantStderr: "ls: cannot access '../nope': No such file or directory\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			l := &Lister{
+				Flags:    tt.flags,
+				Stdout:   stdout,
+				Stderr:   stderr,
+				Now:      func() time.Time { return time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC) },
+				Resolver: fakeResolver{user: "alice", group: "staff"},
+			}
+			if err := l.List(tt.paths); (err != nil) != tt.wantErr {
+				t.Errorf("List() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := stdout.String(); got != tt.wantStdout {
+				t.Errorf("List() stdout = %q, want %q", got, tt.wantStdout)
+			}
+			if got := stderr.String(); got != tt.wantStderr {
+				t.Errorf("List() stderr = %q, want %q", got, tt.wantStderr)
+			}
+		})
+	}
+}
+
+// stripColor removes the escape sequences added by colorName.
+func stripColor(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\033' {
+			for i < len(s) && s[i] != 'm' {
+				i++
+			}
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+func TestLister_List_inodeAndSize(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(filepath.Join(dir, "a"), filepath.Join(dir, "b")); err != nil {
+		t.Skip("hard links not supported:", err)
+	}
+	info, err := os.Stat(filepath.Join(dir, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat := info.Sys().(*syscall.Stat_t)
+	ino, blocks := fmt.Sprint(stat.Ino), fmt.Sprint(stat.Blocks/2)
+	tests := []struct {
+		name  string
+		flags Flags
+		want  string
+	}{
+		{name: "inode", flags: Flags{OnePerLine: true, Inode: true}, want: ino + " a\n" + ino + " b\n"},
+		{name: "size", flags: Flags{Across: true, Width: 80, Size: true}, want: "total " + fmt.Sprint(stat.Blocks) + "\n" + blocks + " a  " + blocks + " b\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			l := &Lister{Flags: tt.flags, Stdout: stdout, Stderr: &bytes.Buffer{}, Resolver: fakeResolver{}}
+			if err := l.List([]string{dir}); err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if got := stdout.String(); got != tt.want {
+				t.Errorf("List() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLister_List_dereference(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"out/a", "src"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []string{"out/a/y", "src/x"} {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{"out/link": "../src", "out/a/up": "..", "src/file": "x"}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			t.Skip("symbolic links not supported:", err)
+		}
+	}
+	out := filepath.Join(dir, "out")
+	tests := []struct {
+		name       string
+		flags      Flags
+		paths      []string
+		wantStdout string
+		wantStderr string
+		wantStatus int
+	}{
+		{name: "test 1", flags: Flags{OnePerLine: true, Recursive: true}, paths: []string{out},
+			wantStdout: out + ":\na\nlink\n\n" + out + "/a:\nup\ny\n"},
+		{name: "test 2", flags: Flags{OnePerLine: true, Recursive: true, Dereference: DerefAlways}, paths: []string{out},
+			wantStdout: out + ":\na\nlink\n\n" + out + "/a:\nup\ny\n\n" + out + "/link:\nfile\nx\n",
+			wantStderr: "ls: " + out + "/a/up: not listing already-listed directory\n", wantStatus: 2},
+		{name: "test 3", flags: Flags{OnePerLine: true}, paths: []string{out + "/link"}, wantStdout: "file\nx\n"},
+		{name: "test 4", flags: Flags{OnePerLine: true, Indicator: IndicatorClassify}, paths: []string{out + "/link"}, wantStdout: out + "/link@\n"},
+		{name: "test 5", flags: Flags{OnePerLine: true, Indicator: IndicatorClassify, Dereference: DerefCommandLine}, paths: []string{out + "/link"}, wantStdout: "file@\nx\n"},
+		{name: "test 6", flags: Flags{OnePerLine: true, Indicator: IndicatorClassify, Dereference: DerefAlways}, paths: []string{dir + "/src"}, wantStdout: "file\nx\n"},
+		{name: "test 7", flags: Flags{OnePerLine: true, Indicator: IndicatorClassify, Dereference: DerefCommandLine}, paths: []string{dir + "/src/file"}, wantStdout: dir + "/src/file\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			l := &Lister{Flags: tt.flags, Stdout: stdout, Stderr: stderr, Resolver: fakeResolver{}}
+			err := l.List(tt.paths)
+			if got := ExitStatus(err); got != tt.wantStatus {
+				t.Errorf("ExitStatus(List()) = %v, want %v (error %v)", got, tt.wantStatus, err)
+			}
+			if got := stdout.String(); got != tt.wantStdout {
+				t.Errorf("List() stdout = %q, want %q", got, tt.wantStdout)
+			}
+			if got := stderr.String(); got != tt.wantStderr {
+				t.Errorf("List() stderr = %q, want %q", got, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestLister_List_dereferenceBroken(t *testing.T) {
+	fsys := fstest.MapFS{
+		"dead":     {Data: []byte("missing"), Mode: fs.ModeSymlink | 0o777},
+		"dir/dead": {Data: []byte("missing"), Mode: fs.ModeSymlink | 0o777},
+	}
+	tests := []struct {
+		name       string
+		paths      []string
+		wantStdout string
+		wantStderr string
+	}{
+		{name: "test 1", paths: []string{"."}, wantStdout: "dead\ndir\n", wantStderr: "ls: cannot access 'dead': No such file or directory\n"},
+		{name: "test 2", paths: []string{"./"}, wantStdout: "dead\ndir\n", wantStderr: "ls: cannot access './dead': No such file or directory\n"},
+		{name: "test 3", paths: []string{"dir"}, wantStdout: "dead\n", wantStderr: "ls: cannot access 'dir/dead': No such file or directory\n"},
+		{name: "test 4", paths: []string{"dir/"}, wantStdout: "dead\n", wantStderr: "ls: cannot access 'dir/dead': No such file or directory\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			l := &Lister{Flags: Flags{OnePerLine: true, Dereference: DerefAlways}, Stdout: stdout, Stderr: stderr, FS: fsys}
+			err := l.List(tt.paths)
+			if got := ExitStatus(err); got != 1 {
+				t.Errorf("ExitStatus(List()) = %v, want 1 (error %v)", got, err)
+			}
+			if got := stdout.String(); got != tt.wantStdout {
+				t.Errorf("List() stdout = %q, want %q", got, tt.wantStdout)
+			}
+			if got := stderr.String(); got != tt.wantStderr {
+				t.Errorf("List() stderr = %q, want %q", got, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestLister_List_brokenLinks(t *testing.T) {
+	dir := t.TempDir()
+	for _, d := range []string{"cur", "rel/sub"} {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cur/app"), nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{"rel/ok": "../cur/app", "rel/stale": "../gone", "rel/sub/up": "../../cur", "rel/sub/old": "missing", "rel/loop": "loop"}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+			t.Skip("symbolic links not supported:", err)
+		}
+	}
+	rel := filepath.Join(dir, "rel")
+	tests := []struct {
+		name  string
+		flags Flags
+		paths []string
+		want  string
+	}{
+		{name: "test 1", flags: Flags{OnePerLine: true, Indicator: IndicatorClassify}, paths: []string{rel}, want: "loop@\nok@\nstale@\nsub/\n"},
+		{name: "test 2", flags: Flags{OnePerLine: true, FindBroken: true}, paths: []string{rel}, want: "loop\nstale\n"},
+		{name: "test 3", flags: Flags{OnePerLine: true, FindBroken: true, Recursive: true}, paths: []string{rel},
+			want: rel + ":\nloop\nstale\n\n" + rel + "/sub:\nold\n"},
+		{name: "test 4", flags: Flags{OnePerLine: true, FindBroken: true}, paths: []string{rel + "/ok", rel + "/stale"}, want: rel + "/stale\n"},
+		{name: "test 5", flags: Flags{NDJSON: true, FindBroken: true, Recursive: true}, paths: []string{rel},
+			want: `"path":"` + rel + `/loop"|"path":"` + rel + `/stale"|"path":"` + rel + `/sub/old"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			l := &Lister{Flags: tt.flags, Stdout: stdout, Stderr: &bytes.Buffer{}, Resolver: fakeResolver{}}
+			if err := l.List(tt.paths); err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			got := stdout.String()
+			if tt.flags.NDJSON {
+				var paths []string
+				for _, line := range strings.Split(strings.TrimSpace(got), "\n") {
+					start := strings.Index(line, `"path":`)
+					end := strings.Index(line[start:], `",`)
+					paths = append(paths, line[start:start+end+1])
+				}
+				got = strings.Join(paths, "|")
+			}
+			if got != tt.want {
+				t.Errorf("List() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLister_List_linkTargets(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "a/b"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a/run"), nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// The target is relative to the directory of the link, not to the
+	// directory being listed.
+	if err := os.Symlink("../run", filepath.Join(dir, "a/b/link")); err != nil {
+		t.Skip("symbolic links not supported:", err)
+	}
+	stdout := &bytes.Buffer{}
+	l := &Lister{Flags: Flags{Long: true, Indicator: IndicatorClassify}, Stdout: stdout, Stderr: &bytes.Buffer{}, Resolver: fakeResolver{}}
+	if err := l.List([]string{dir + "/a/b"}); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if got := stdout.String(); !strings.HasSuffix(got, " link -> ../run*\n") {
+		t.Errorf("List() = %q, want the executable target ../run*", got)
+	}
+}
@@ -1,47 +1,83 @@
 package lsfunctions
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
 	"strings"
 )
 
-// listPath lists the contents of a specified directory path based on the given flags.
+// ListPath lists the contents of a specified directory path based on the given flags.
+// It is a shorthand for NewLister(flags).ListPath(path).
+func ListPath(path string, flags Flags) error {
+	return NewLister(flags).ListPath(path)
+}
+
+// ListPath lists the contents of a specified directory path.
+// With the Recursive flag, every subdirectory is listed after it under its own header.
+// Problems are reported on Stderr as they are found.
 //
 // Parameters:
 //   - path: A string representing the directory path to list.
-//   - flags: A Flags struct containing boolean flags to control the behavior of the listing.
 //
 // Returns:
-//   - error: An error if there was a problem reading the directory or displaying its contents.
+//   - error: The problems met while listing, joined as *ListError values.
 //     Returns nil if the operation was successful.
-func ListPath(path string, flags Flags) error {
-	entries, err := readDir(path, flags)
+func (l *Lister) ListPath(path string) error {
+	run := l.newRun(context.Background())
+	w := l.newWalker(run.ctx)
+	defer w.stop()
+	l.listDir(run, w, w.read([]string{path})[0], false, true)
+	return run.err()
+}
+
+// listDir lists the directory r, under a "path:" header when header is
+// set, and then its subdirectories with the Recursive flag. As in GNU ls,
+// nothing is written to Stdout for a directory that cannot be read.
+// operand tells whether the directory was given on the command line.
+// The subdirectories are read by w while the ones before them are listed;
+// nothing more is listed once the run is cancelled.
+func (l *Lister) listDir(run *listRun, w *walker, r *dirRead, header, operand bool) {
+	if !w.wait(r) {
+		return
+	}
+	path := r.path
+	if r.hasID {
+		leave, ok := run.enter(path, r.id)
+		if !ok {
+			return
+		}
+		defer leave()
+	}
+	entries, err := r.entries, r.err
 	if err != nil {
-		return err
-	}
-	if flags.Long {
-		DisplayLongFormat(os.Stdout, entries)
-	} else {
-		DisplayShortList(os.Stdout, entries)
-	}
-	if flags.Recursive {
-		for _, entry := range entries {
-			if entry.Info.IsDir() {
-				if entry.Name == ".." || entry.Name == "." {
-					continue
-				}
-				fmt.Println()
-				newPath := joinPath(path, entry.Name)
-				fmt.Printf("%s:\n", newPath)
-				if err := ListPath(newPath, flags); err != nil {
-					fmt.Fprintf(os.Stdout, "total 0\n")
-					fmt.Fprintf(os.Stderr, "ls: cannot open directory '%s': Permission denied\n", newPath)
-				}
-			}
+		markOperand(err, entries, operand)
+		run.report(err)
+		if entries == nil {
+			return
+		}
+	}
+	if run.started {
+		fmt.Fprintln(l.Stdout)
+	}
+	run.started = true
+	if header {
+		fmt.Fprintf(l.Stdout, "%s:\n", l.quote(path, ":"))
+	}
+	l.display(entries, true)
+	if !l.Flags.Recursive {
+		return
+	}
+	var subdirs []string
+	for _, entry := range entries {
+		if l.canDescend(entry) {
+			subdirs = append(subdirs, joinPath(path, entry.Name))
 		}
 	}
-	return nil
+	for _, sub := range w.read(subdirs) {
+		l.listDir(run, w, sub, true, false)
+	}
 }
 
 // readDir reads the contents of a directory and returns a slice of FileInfo structures.
@@ -49,102 +85,143 @@ func ListPath(path string, flags Flags) error {
 //
 // Parameters:
 //   - path: A string representing the directory path to read.
-//   - flags: A Flags struct containing boolean flags to control the behavior of the function.
 //
 // Returns:
 //   - []FileInfo: A slice of FileInfo structures containing information about the directory entries.
-//   - error: An error if there was a problem reading the directory or its contents.
-func readDir(path string, flags Flags) ([]FileDetails, error) {
-	info, err := os.Lstat(path)
-	if err != nil {
-		return nil, fmt.Errorf("error accessing path %s: %w", path, err)
+//   - error: A *ListError if the directory could not be read, in which case
+//     no entries are returned. When entries are returned along with an
+//     error, it joins the problems met with the entries that were left out.
+func (l *Lister) readDir(path string) ([]FileDetails, error) {
+	flags := l.Flags
+	fsys := l.fsys()
+	info, err := fsys.Lstat(path)
+	if err == nil && info.Mode()&fs.ModeSymlink != 0 && l.dereference() != DerefNever {
+		// A link the Lister was asked to follow, given on the command
+		// line or met with -L.
+		info, err = fsys.Stat(path)
 	}
-	if !info.IsDir() && flags.Long {
-		return handleNonDirectory(path, info, flags)
-	}
-
-	dir, err := os.Open(path)
 	if err != nil {
-		return nil, err
+		return nil, &ListError{Op: "cannot access", Path: path, Err: err}
+	}
+	if !info.IsDir() && !l.isArchive(path, info) && flags.Long {
+		return l.handleNonDirectory(path, info)
 	}
-	defer dir.Close()
 
-	files, err := dir.ReadDir(-1)
+	files, err := fsys.ReadDir(path)
 	if err != nil {
-		return nil, err
+		return nil, &ListError{Op: "cannot open directory", Path: path, Err: err}
 	}
 	entries := make([]FileDetails, 0, len(files)+2)
 
 	// Add entries for parents directory and current directory
 	if flags.All {
-		entries = append(entries, createDotEntry(path)...)
+		entries = append(entries, createDotEntry(fsys, path)...)
 	}
 
+	visible := files[:0]
 	for _, file := range files {
-		if !flags.All && strings.HasPrefix(file.Name(), ".") {
-			continue
+		if flags.All || !strings.HasPrefix(file.Name(), ".") {
+			visible = append(visible, file)
 		}
-		fileInfo, err := file.Info()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "warning: could not get info for %s: %v\n", file.Name(), err)
-			continue
+	}
+	// The details of the entries are gathered on several goroutines, each
+	// one filling in its own slot, and collected in directory order.
+	details := make([]FileDetails, len(visible))
+	found := make([]bool, len(visible))
+	errs := make([]error, len(visible))
+	l.pool().forEach(len(visible), func(i int) {
+		details[i], found[i], errs[i] = l.readEntry(fsys, path, visible[i])
+	})
+	for i := range visible {
+		if found[i] {
+			entries = append(entries, details[i])
 		}
-		entry := createFileDetails(path, file.Name(), fileInfo)
-		entries = append(entries, entry)
 	}
 
-	return sortEntries(entries, flags), nil
+	l.fillBirthTimes(entries)
+	return l.sortEntries(entries), errors.Join(errs...)
 }
 
-func handleNonDirectory(path string, info os.FileInfo, flags Flags) ([]FileDetails, error) {
-	entry := FileDetails{Name: path, Info: info}
-	if flags.Long {
-		if info.Mode()&os.ModeSymlink != 0 {
-			if linkTarget, err := os.Readlink(path); err == nil {
-				// _, err := getLinkTargetType(path, linkTarget)
-				// if err != nil {
-				// 	if strings.Contains(err.Error(), "target not found") {
-				// 		entry.IsBrokenLink = true
-				// 		// entry.TargetInfo = TargetInfo{Name: linkTarget, Mode: newEntry.Mode, IsBrokenLink: true}
-				// 	} 
-				// }
-				// entry.TargetInfo = TargetInfo{Name: linkTarget, Mode: newEntry.Mode}
-				entry.LinkTarget = linkTarget
-			}
+// readEntry returns the details of the entry file of the directory path.
+// It reports false, with the problem, when the entry cannot be described.
+// An entry that is listed despite a problem, as a link that -L cannot
+// follow, comes with it.
+func (l *Lister) readEntry(fsys fileSystem, path string, file fs.DirEntry) (FileDetails, bool, error) {
+	fileInfo, err := file.Info()
+	if err != nil {
+		return FileDetails{}, false, &ListError{Op: "cannot access", Path: reportPath(path, file.Name()), Err: err}
+	}
+	if fileInfo.Mode()&fs.ModeSymlink != 0 && l.dereference() == DerefAlways {
+		// With -L, the file the link points to is listed in its place.
+		// A dangling link is reported and listed as a link.
+		if target, err := fsys.Stat(joinPath(path, file.Name())); err == nil {
+			fileInfo = target
+		} else {
+			return createFileDetails(fsys, path, file.Name(), fileInfo), true, &ListError{Op: "cannot access", Path: reportPath(path, file.Name()), Err: err}
 		}
 	}
-	return []FileDetails{entry}, nil
+	return createFileDetails(fsys, path, file.Name(), fileInfo), true, nil
+}
+
+// handleNonDirectory returns the details of a path that is listed as a file
+// rather than as a directory, such as a file given on the command line.
+func (l *Lister) handleNonDirectory(path string, info fs.FileInfo) ([]FileDetails, error) {
+	entry := FileDetails{Name: path, Path: path, Info: info}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		setLinkTarget(l.fsys(), &entry)
+	}
+	entries := []FileDetails{entry}
+	l.fillBirthTimes(entries)
+	return entries, nil
 }
 
-func createFileDetails(path, name string, info os.FileInfo) FileDetails {
+// createFileDetails returns the details of the entry name in the directory path.
+func createFileDetails(fsys fileSystem, path, name string, info fs.FileInfo) FileDetails {
 	entry := FileDetails{Name: name, Info: info}
 	setEntryPath(path, &entry)
-	if info.Mode()&os.ModeSymlink != 0 {
-		newPath := joinPath(path, name)
-		if linkTarget, err := os.Readlink(newPath); err == nil {
-			// _, err := getLinkTargetType(path, linkTarget)
-			// if err != nil {
-			// 	if strings.Contains(err.Error(), "target not found") {
-			// 		entry.IsBrokenLink = true
-			// 		// entry.TargetInfo = TargetInfo{Name: linkTarget, Mode: newEntry.Mode, IsBrokenLink: true}
-			// 	} 
-			// }
-			// entry.TargetInfo = TargetInfo{Name: linkTarget, Mode: newEntry.Mode}
-			entry.LinkTarget = linkTarget
-		}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		setLinkTarget(fsys, &entry)
 	}
 	return entry
 }
 
-func createDotEntry(path string) []FileDetails {
+// setLinkTarget reads the target of the symbolic link entry and describes
+// the file it leads to in TargetInfo. The file is found the way the system
+// finds it, relative to the link's own directory and through any further
+// links. A link that leads to no file, or into a loop, is marked broken.
+// Nothing is filled in when the file system cannot read links.
+func setLinkTarget(fsys fileSystem, entry *FileDetails) {
+	target, err := fsys.ReadLink(entry.Path)
+	if err != nil {
+		return
+	}
+	entry.LinkTarget = target
+	entry.TargetInfo = TargetInfo{Name: target, Path: resolveLinkTarget(entry.Path, target)}
+	info, err := fsys.Stat(entry.Path)
+	if err != nil {
+		entry.IsBrokenLink = true
+		entry.TargetInfo.IsBrokenLink = true
+		return
+	}
+	entry.TargetInfo.Mode, _ = formatPermissionsWithACL(fsys, entry.TargetInfo.Path, info.Mode())
+	entry.TargetInfo.Type = getFileType(Entry{Mode: entry.TargetInfo.Mode})
+}
+
+// createDotEntry returns the "." and ".." entries of the directory path.
+// They are listed ahead of the others when entries are left in directory
+// order: the system's directory reading, as Go does it, leaves them out, so
+// where the directory holds them is not known.
+func createDotEntry(fsys fileSystem, path string) []FileDetails {
 	var entries []FileDetails
-	if currentInfo, err := os.Stat(path); err == nil {
+	if currentInfo, err := fsys.Stat(path); err == nil {
 		currentEntry := FileDetails{Name: ".", Info: currentInfo}
+		setEntryPath(path, &currentEntry)
 		entries = append(entries, currentEntry)
 	}
 	parentDir := getParentDir(path)
-	if parentInfo, err := os.Stat(parentDir); err == nil {
+	if parentInfo, err := fsys.Stat(parentDir); err == nil {
 		parentEntry := FileDetails{Name: "..", Info: parentInfo}
+		setEntryPath(path, &parentEntry)
 		entries = append(entries, parentEntry)
 	}
 	return entries
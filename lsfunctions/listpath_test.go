@@ -1,9 +1,12 @@
 package lsfunctions
 
 import (
+	"archive/tar"
+	"io/fs"
 	"os"
 	"reflect"
 	"testing"
+	"testing/fstest"
 	"time"
 )
 
@@ -80,7 +83,7 @@ func Test_readDir(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := readDir(tt.args.path, tt.args.flags)
+			got, err := NewLister(tt.args.flags).readDir(tt.args.path)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("readDir() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -171,7 +174,9 @@ func Test_quickSort(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			quickSort(tt.args.entries, tt.args.low, tt.args.high, tt.args.flags)
+			quickSort(tt.args.entries, tt.args.low, tt.args.high, func(a, b FileDetails) bool {
+				return compareEntries(a, b, tt.args.flags)
+			})
 		})
 		for i, entry := range tt.args.entries {
 			if !reflect.DeepEqual(entry.Name, tt.args.want[i].Name) {
@@ -209,3 +214,151 @@ func Test_compareEntries(t *testing.T) {
 		})
 	}
 }
+
+func Test_compareVersions(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want int
+	}{
+		{name: "numbers", a: "file2", b: "file10", want: -1},
+		{name: "leading zeros", a: "file010", b: "file9", want: 1},
+		{name: "versions", a: "app-1.9.2", b: "app-1.10.0", want: -1},
+		{name: "suffix breaks ties only", a: "foo-1.2.tar.gz", b: "foo-1.10.tar", want: -1},
+		{name: "tilde first", a: "1.0~rc1", b: "1.0", want: -1},
+		{name: "hidden first", a: ".zshrc", b: "a", want: -1},
+		{name: "dot entries", a: "..", b: ".bashrc", want: -1},
+		{name: "equal", a: "x1", b: "x1", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := compareVersions(tt.a, tt.b)
+			if (got < 0 && tt.want >= 0) || (got > 0 && tt.want <= 0) || (got == 0 && tt.want != 0) {
+				t.Errorf("compareVersions(%q, %q) = %v, want sign of %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sortEntries_keys(t *testing.T) {
+	now := time.Now()
+	entries := func() []FileDetails {
+		return []FileDetails{
+			{Name: "b10.txt", Info: mockFileInfo{size: 30, modTime: now.Add(-time.Hour)}},
+			{Name: "a.go", Info: mockFileInfo{size: 10, modTime: now}},
+			{Name: "b9.md", Info: mockFileInfo{size: 20, modTime: now.Add(-2 * time.Hour)}},
+			{Name: "README", Info: mockFileInfo{size: 20, modTime: now.Add(-time.Hour)}},
+		}
+	}
+	tests := []struct {
+		name  string
+		flags Flags
+		want  []string
+	}{
+		{name: "name", flags: Flags{}, want: []string{"a.go", "b10.txt", "b9.md", "README"}},
+		{name: "size", flags: Flags{Sort: SortSize}, want: []string{"b10.txt", "b9.md", "README", "a.go"}},
+		{name: "time", flags: Flags{Sort: SortTime}, want: []string{"a.go", "b10.txt", "README", "b9.md"}},
+		{name: "extension", flags: Flags{Sort: SortExtension}, want: []string{"README", "a.go", "b9.md", "b10.txt"}},
+		{name: "version", flags: Flags{Sort: SortVersion}, want: []string{"README", "a.go", "b9.md", "b10.txt"}},
+		{name: "none", flags: Flags{Sort: SortNone, Reverse: true}, want: []string{"b10.txt", "a.go", "b9.md", "README"}},
+		{name: "reverse size", flags: Flags{Sort: SortSize, Reverse: true}, want: []string{"a.go", "README", "b9.md", "b10.txt"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sortEntries(entries(), tt.flags)
+			var names []string
+			for _, entry := range got {
+				names = append(names, entry.Name)
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("sortEntries() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func Test_entryTime(t *testing.T) {
+	mtime := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	atime := mtime.Add(time.Hour)
+	ctime := mtime.Add(2 * time.Hour)
+	tarEntry := FileDetails{Info: (&tar.Header{Name: "a", ModTime: mtime, AccessTime: atime, ChangeTime: ctime}).FileInfo()}
+	plain := FileDetails{Info: mockFileInfo{modTime: mtime}}
+	born := FileDetails{Info: mockFileInfo{modTime: mtime}, BirthTime: mtime.Add(-time.Hour)}
+	tests := []struct {
+		name   string
+		entry  FileDetails
+		field  TimeField
+		want   time.Time
+		wantOk bool
+	}{
+		{name: "test 1", entry: tarEntry, field: TimeModification, want: mtime, wantOk: true},
+		{name: "test 2", entry: tarEntry, field: TimeAccess, want: atime, wantOk: true},
+		{name: "test 3", entry: tarEntry, field: TimeChange, want: ctime, wantOk: true},
+		{name: "test 4", entry: plain, field: TimeChange, want: mtime, wantOk: true},
+		{name: "test 5", entry: plain, field: TimeBirth, want: time.Time{}, wantOk: false},
+		{name: "test 6", entry: born, field: TimeBirth, want: mtime.Add(-time.Hour), wantOk: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := entryTime(tt.entry, tt.field)
+			if !got.Equal(tt.want) || ok != tt.wantOk {
+				t.Errorf("entryTime() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_sortEntries_timeField(t *testing.T) {
+	now := time.Now()
+	entries := []FileDetails{
+		{Name: "old", Info: (&tar.Header{Name: "old", ModTime: now.Add(-time.Hour), ChangeTime: now}).FileInfo()},
+		{Name: "new", Info: (&tar.Header{Name: "new", ModTime: now, ChangeTime: now.Add(-time.Hour)}).FileInfo()},
+	}
+	l := &Lister{Flags: Flags{TimeField: TimeChange}}
+	if got := l.sortEntries(entries); got[0].Name != "old" {
+		t.Errorf("sortEntries() with -c = %v, want old first", []string{got[0].Name, got[1].Name})
+	}
+	l.Flags.Long = true
+	if got := l.sortEntries(entries); got[0].Name != "new" {
+		t.Errorf("sortEntries() with -lc = %v, want new first", []string{got[0].Name, got[1].Name})
+	}
+}
+
+func TestLister_Less(t *testing.T) {
+	l := &Lister{Less: func(a, b FileDetails) bool { return len(a.Name) < len(b.Name) }}
+	got := l.sortEntries([]FileDetails{{Name: "ccc"}, {Name: "a"}, {Name: "bb"}})
+	if got[0].Name != "a" || got[1].Name != "bb" || got[2].Name != "ccc" {
+		t.Errorf("sortEntries() with Less = %v", got)
+	}
+}
+
+func Test_setLinkTarget(t *testing.T) {
+	fsys := ioFS{fsys: fstest.MapFS{
+		"docs/readme.md": {Data: []byte("hello"), Mode: 0o644},
+		"docs/latest":    {Data: []byte("readme.md"), Mode: fs.ModeSymlink | 0o777},
+		"top":            {Data: []byte("docs/latest"), Mode: fs.ModeSymlink | 0o777},
+		"docs/stale":     {Data: []byte("../gone"), Mode: fs.ModeSymlink | 0o777},
+	}}
+	tests := []struct {
+		name       string
+		path       string
+		want       TargetInfo
+		wantBroken bool
+	}{
+		{name: "test 1", path: "docs/latest", want: TargetInfo{Name: "readme.md", Path: "docs/readme.md", Mode: "-rw-r--r--", Type: "fi"}},
+		{name: "test 2", path: "top", want: TargetInfo{Name: "docs/latest", Path: "docs/latest", Mode: "-rw-r--r--", Type: "fi"}},
+		{name: "test 3", path: "docs/stale", want: TargetInfo{Name: "../gone", Path: "gone", IsBrokenLink: true}, wantBroken: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := FileDetails{Path: tt.path}
+			setLinkTarget(fsys, &entry)
+			if entry.TargetInfo != tt.want {
+				t.Errorf("setLinkTarget() TargetInfo = %+v, want %+v", entry.TargetInfo, tt.want)
+			}
+			if entry.IsBrokenLink != tt.wantBroken {
+				t.Errorf("setLinkTarget() IsBrokenLink = %v, want %v", entry.IsBrokenLink, tt.wantBroken)
+			}
+		})
+	}
+}
@@ -3,34 +3,85 @@ package lsfunctions
 import (
 	"fmt"
 	"io"
+	"strings"
 )
 
-// DisplayLongFormat displays the entries in long format.
+// DisplayLongFormat displays the entries of a directory in long format, preceded by their total size.
 func DisplayLongFormat(w io.Writer, entries []FileDetails) {
-	t := getTotalBlocks(entries)
-	if ShowTotals {
-		fmt.Fprintf(w, "total %d\n", t)
+	l := NewLister(Flags{Long: true})
+	l.Stdout = w
+	l.displayLongFormat(entries, true)
+}
+
+// displayLongFormat displays the entries in long format.
+// The "total" line is only written when total is set, as it is for directory contents.
+func (l *Lister) displayLongFormat(entries []FileDetails, total bool) {
+	if total {
+		l.writeTotal(entries)
 	}
-	formattedEntries := prepareFileDetailsForDisplay(entries)
+	formattedEntries := l.prepareFileDetailsForDisplay(entries)
 	widths := getWidths(formattedEntries)
 	for _, entry := range formattedEntries {
-		fmt.Fprintln(w, getLongFormatString(entry, widths))
+		fmt.Fprintln(l.Stdout, l.getLongFormatString(entry, widths))
+	}
+}
+
+// padID pads an owner or group to width: names are aligned to the left
+// and numeric IDs to the right, as GNU ls does.
+func padID(s string, numeric bool, width int) string {
+	if numeric {
+		return padLeft(s, width)
+	}
+	return padRight(s, width)
+}
+
+// sizeColumn returns the size of an entry padded to the size column or,
+// for a device, its major and minor numbers as "major, minor", each
+// aligned with those of the other devices, as GNU ls does.
+func sizeColumn(e Entry, w Widths) string {
+	if e.Major == "" {
+		return padLeft(e.Size, w.sizeCol)
 	}
+	majorWidth := getMax(w.majorCol, w.sizeCol-2-w.minorCol)
+	return padLeft(e.Major, majorWidth) + ", " + padLeft(e.Minor, w.minorCol)
+}
+
+// writeTotal writes the "total" line: the space allocated to the entries,
+// in the units of the block size.
+func (l *Lister) writeTotal(entries []FileDetails) {
+	_, blockFormat := l.sizeFormats()
+	fmt.Fprintf(l.Stdout, "total %s\n", blockFormat.format(uint64(getTotalBlocks(entries))*512))
 }
 
 // getLongFormatString returns the long format string with the given entry and widths.
-// The width of each column is determined by the maximum width of the corresponding column in the input entries.
+// The width of each column is determined by the maximum width of the corresponding column in the input entries,
+// and columns are padded by their display width rather than their length in bytes.
 // If the entry is a symbolic link, the link target is also displayed in color.
-func getLongFormatString(e Entry, w Widths) string {
-	e = colorName(e, false)
-	s := ""
-	if w.minorCol == 0 {
-		s = fmt.Sprintf("%-*s %*s %-*s %-*s %*s %*s  %s", w.modCol, e.Mode, w.linkCol, e.LinkCount, w.ownerCol, e.Owner, w.groupCol, e.Group, w.sizeCol, e.Size, w.timeCol, e.Time, e.Name)
-	} else {
-		s = fmt.Sprintf("%-*s %*s %-*s %-*s %*s %*s %*s  %s", w.modCol, e.Mode, w.linkCol, e.LinkCount, w.ownerCol, e.Owner, w.groupCol, e.Group, w.minorCol, e.Minor, w.sizeCol, e.Size, w.timeCol, e.Time, e.Name)
+// The indicator of the entry, or of its target, ends the line.
+func (l *Lister) getLongFormatString(e Entry, w Widths) string {
+	e = l.colorName(e, false)
+	columns := []string{padRight(e.Mode, w.modCol), padLeft(e.LinkCount, w.linkCol)}
+	owner := padID(e.Owner, e.NumericOwner, w.ownerCol)
+	if !l.Flags.NoOwner {
+		columns = append(columns, owner)
+	}
+	if !l.Flags.NoGroup {
+		columns = append(columns, padID(e.Group, e.NumericGroup, w.groupCol))
 	}
+	if l.Flags.Author {
+		columns = append(columns, owner)
+	}
+	time := padRight(e.Time, w.timeCol)
+	if e.Time == "?" {
+		// Like GNU ls, an unknown time is aligned to the right.
+		time = padLeft(e.Time, w.timeCol)
+	}
+	columns = append(columns, sizeColumn(e, w), time, e.Name)
+	s := l.numberColumns(e, w) + strings.Join(columns, " ")
 	if e.Mode[0] == 'l' && e.LinkTarget != "" {
-		s += " -> " + colorLinkTarget(e.Path, e.LinkTarget)
+		s += " -> " + l.colorLinkTarget(e)
+	} else {
+		s += e.Indicator
 	}
 	return s
 }
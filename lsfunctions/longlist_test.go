@@ -0,0 +1,143 @@
+package lsfunctions
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLister_getLongFormatString_owners(t *testing.T) {
+	entries := []Entry{
+		{Name: "a", Mode: "-rw-r--r--", LinkCount: "1", Owner: "alice", Group: "staff", Size: "5", Time: "Jan  1 00:00"},
+		{Name: "b", Mode: "-rw-r--r--", LinkCount: "1", Owner: "42", Group: "7", Size: "10", Time: "Jan  1 00:00", NumericOwner: true, NumericGroup: true},
+	}
+	w := getWidths(entries)
+	tests := []struct {
+		name  string
+		flags Flags
+		want  []string
+	}{
+		{name: "test 1", flags: Flags{}, want: []string{
+			"-rw-r--r-- 1 alice staff  5 Jan  1 00:00 a",
+			"-rw-r--r-- 1    42     7 10 Jan  1 00:00 b",
+		}},
+		{name: "test 2", flags: Flags{NoOwner: true}, want: []string{
+			"-rw-r--r-- 1 staff  5 Jan  1 00:00 a",
+			"-rw-r--r-- 1     7 10 Jan  1 00:00 b",
+		}},
+		{name: "test 3", flags: Flags{NoGroup: true}, want: []string{
+			"-rw-r--r-- 1 alice  5 Jan  1 00:00 a",
+			"-rw-r--r-- 1    42 10 Jan  1 00:00 b",
+		}},
+		{name: "test 4", flags: Flags{NoOwner: true, NoGroup: true, Author: true}, want: []string{
+			"-rw-r--r-- 1 alice  5 Jan  1 00:00 a",
+			"-rw-r--r-- 1    42 10 Jan  1 00:00 b",
+		}},
+		{name: "test 5", flags: Flags{Author: true}, want: []string{
+			"-rw-r--r-- 1 alice staff alice  5 Jan  1 00:00 a",
+			"-rw-r--r-- 1    42     7    42 10 Jan  1 00:00 b",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.flags.Color = ColorNever
+			l := &Lister{Flags: tt.flags}
+			for i, e := range entries {
+				if got := l.getLongFormatString(e, w); got != tt.want[i] {
+					t.Errorf("getLongFormatString() = %q, want %q", got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func Test_makedev(t *testing.T) {
+	tests := []struct {
+		name         string
+		major, minor uint64
+		dev          uint64
+	}{
+		{name: "test 1", major: 1, minor: 3, dev: 0x103},
+		{name: "test 2", major: 7, minor: 256, dev: 0x100700},
+		{name: "test 3", major: 259, minor: 65536, dev: 0x10010300},
+		{name: "test 4", major: 4095, minor: 255, dev: 0xfffff},
+		{name: "test 5", major: 0x12345, minor: 0xabcdef, dev: 0x1200abcd345ef},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := makedev(tt.major, tt.minor); got != tt.dev {
+				t.Errorf("makedev(%d, %d) = %#x, want %#x", tt.major, tt.minor, got, tt.dev)
+			}
+			if got := major(tt.dev); got != tt.major {
+				t.Errorf("major(%#x) = %d, want %d", tt.dev, got, tt.major)
+			}
+			if got := minor(tt.dev); got != tt.minor {
+				t.Errorf("minor(%#x) = %d, want %d", tt.dev, got, tt.minor)
+			}
+		})
+	}
+}
+
+func TestLister_getLongFormatString_devices(t *testing.T) {
+	entries := []Entry{
+		{Name: "big", Mode: "-rw-r--r--", LinkCount: "1", Owner: "root", Group: "root", Size: "123456789", Time: "Jan  1 00:00"},
+		{Name: "null", Mode: "crw-rw-rw-", LinkCount: "1", Owner: "root", Group: "root", Major: "1", Minor: "3", Time: "Jan  1 00:00"},
+		{Name: "nvme0n1", Mode: "brw-rw----", LinkCount: "1", Owner: "root", Group: "disk", Major: "259", Minor: "0", Time: "Jan  1 00:00"},
+		{Name: "tty1", Mode: "crw--w----", LinkCount: "1", Owner: "root", Group: "tty", Major: "4", Minor: "1", Time: "Jan  1 00:00"},
+	}
+	tests := []struct {
+		name    string
+		entries []Entry
+		want    []string
+	}{
+		{name: "test 1", entries: entries[1:], want: []string{
+			"crw-rw-rw- 1 root root   1, 3 Jan  1 00:00 null",
+			"brw-rw---- 1 root disk 259, 0 Jan  1 00:00 nvme0n1",
+			"crw--w---- 1 root tty    4, 1 Jan  1 00:00 tty1",
+		}},
+		{name: "test 2", entries: entries, want: []string{
+			"-rw-r--r-- 1 root root 123456789 Jan  1 00:00 big",
+			"crw-rw-rw- 1 root root      1, 3 Jan  1 00:00 null",
+			"brw-rw---- 1 root disk    259, 0 Jan  1 00:00 nvme0n1",
+			"crw--w---- 1 root tty       4, 1 Jan  1 00:00 tty1",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &Lister{Flags: Flags{Long: true}}
+			w := getWidths(tt.entries)
+			for i, e := range tt.entries {
+				if got := l.getLongFormatString(e, w); got != tt.want[i] {
+					t.Errorf("getLongFormatString() = %q, want %q", got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLister_List_devices(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "test 1", path: "/dev/null", want: " 1, 3 "},
+		{name: "test 2", path: "/dev/loop0", want: " 7, 0 "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := os.Lstat(tt.path); err != nil {
+				t.Skip(err)
+			}
+			stdout := &bytes.Buffer{}
+			l := &Lister{Flags: Flags{Long: true}, Stdout: stdout, Stderr: &bytes.Buffer{}, Resolver: fakeResolver{}}
+			if err := l.List([]string{tt.path}); err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if got := stdout.String(); !strings.Contains(got, tt.want) {
+				t.Errorf("List() = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
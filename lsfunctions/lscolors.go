@@ -0,0 +1,433 @@
+package lsfunctions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// colorKeys lists the two-letter keys of LS_COLORS in the order they are printed.
+var colorKeys = []string{
+	"lc", "rc", "ec", "rs", "no", "fi", "di", "ln", "mh", "pi", "so", "do",
+	"bd", "cd", "mi", "or", "ex", "su", "sg", "ca", "tw", "ow", "st", "cl",
+}
+
+// ColorScheme holds the colours of file names, in the form of the
+// LS_COLORS environment variable: SGR codes such as "01;34" for each kind
+// of file (di for directories, ln for symbolic links, ...) and for names
+// ending with a given suffix, such as "*.tar".
+type ColorScheme struct {
+	// types maps the two-letter keys to their codes.
+	types map[string]string
+	// exts holds the suffix rules in the order they were given; the
+	// last rule that matches a name wins.
+	exts []extColor
+	// linkAsTarget is set by "ln=target", which colours a link like
+	// the file it points to.
+	linkAsTarget bool
+}
+
+// extColor is the code used for names ending with suffix.
+type extColor struct {
+	suffix, code string
+}
+
+// defaultColorTypes are the codes of each kind of file when no scheme is given.
+var defaultColorTypes = map[string]string{
+	"lc": "\033[",
+	"rc": "m",
+	"rs": "0",
+	"di": "1;38;5;01;34",
+	"ln": "1;38;2;42;161;179",
+	"pi": "38;2;162;115;76;40",
+	"so": "1;38;2;163;71;181",
+	"bd": "1;38;2;162;115;76;40",
+	"cd": "1;38;2;162;115;76;40",
+	"ex": "1;38;2;39;169;105",
+	"su": "48;2;192;28;20",
+	"sg": "48;2;162;115;76;30",
+	"st": "42;30",
+	"or": "40;31;01",
+}
+
+// defaultColorExts are the suffix rules of the built-in scheme.
+var defaultColorExts = []struct {
+	code string
+	exts []string
+}{
+	{"1;31", []string{".7z", ".deb", ".gz", ".rar", ".tar", ".tgz", ".zip"}},
+	{"1;38;2;162;71;186", []string{".avi", ".flv", ".mkv", ".mov", ".mp4", ".mpeg", ".mpg", ".webm"}},
+	{"1;96", []string{".flac", ".mp3", ".wav"}},
+	{"1;35", []string{".gif", ".jpeg", ".jpg", ".png", ".webp"}},
+	{"1;38;5;8", []string{".crdownload"}},
+}
+
+// DefaultColors returns the built-in colour scheme, used when LS_COLORS is not set.
+func DefaultColors() *ColorScheme {
+	c := newColorScheme()
+	for _, group := range defaultColorExts {
+		for _, ext := range group.exts {
+			c.exts = append(c.exts, extColor{suffix: ext, code: group.code})
+		}
+	}
+	return c
+}
+
+// LoadColors returns the colour scheme of the environment. With a file
+// name, the scheme is read from that dircolors database, matched against
+// the TERM and COLORTERM environment variables. Otherwise it is parsed from
+// LS_COLORS, and the built-in scheme is used when that is not set. When
+// LS_COLORS cannot be parsed, the returned scheme colours nothing, as with
+// GNU ls, and the error is meant as a warning.
+func LoadColors(file string) (*ColorScheme, error) {
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, errorText(err))
+		}
+		defer f.Close()
+		c, err := ParseDircolors(f, os.Getenv("TERM"), os.Getenv("COLORTERM"))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		return c, nil
+	}
+	value := os.Getenv("LS_COLORS")
+	if value == "" {
+		return DefaultColors(), nil
+	}
+	c, err := ParseLSColors(value)
+	if err != nil {
+		return &ColorScheme{types: map[string]string{}}, err
+	}
+	return c, nil
+}
+
+// newColorScheme returns a scheme with the built-in codes for each kind of
+// file and no suffix rules, which LS_COLORS and dircolors databases add to.
+func newColorScheme() *ColorScheme {
+	c := &ColorScheme{types: make(map[string]string, len(defaultColorTypes))}
+	for key, code := range defaultColorTypes {
+		c.types[key] = code
+	}
+	return c
+}
+
+// ParseLSColors parses a colour scheme in the format of the LS_COLORS
+// environment variable, a list of "key=code" items separated by colons,
+// such as "di=01;34:ln=01;36:*.tar=01;31". Codes may use the escapes of
+// GNU ls, such as "\e" or "^[" for the escape character. Kinds of file
+// that the value does not mention keep their built-in colours.
+func ParseLSColors(s string) (*ColorScheme, error) {
+	c := newColorScheme()
+	for i := 0; i < len(s); {
+		if s[i] == ':' {
+			i++
+			continue
+		}
+		var key string
+		if s[i] == '*' {
+			suffix, next, err := scanColorString(s, i+1, true)
+			if err != nil || next == len(s) || s[next] != '=' {
+				return nil, errLSColors
+			}
+			key, i = "*"+suffix, next
+		} else {
+			if i+2 >= len(s) || s[i+2] != '=' {
+				return nil, errLSColors
+			}
+			key, i = s[i:i+2], i+2
+		}
+		code, next, err := scanColorString(s, i+1, false)
+		if err != nil {
+			return nil, errLSColors
+		}
+		if err := c.set(key, code); err != nil {
+			return nil, errLSColors
+		}
+		i = next
+	}
+	return c, nil
+}
+
+// errLSColors is the error of an LS_COLORS value that cannot be parsed,
+// worded like GNU ls's warning.
+var errLSColors = errors.New("unparsable value for LS_COLORS environment variable")
+
+// set gives a key of the scheme, such as "di" or "*.tar", its code.
+func (c *ColorScheme) set(key, code string) error {
+	if suffix, ok := strings.CutPrefix(key, "*"); ok {
+		c.exts = append(c.exts, extColor{suffix: suffix, code: code})
+		return nil
+	}
+	if !isColorKey(key) {
+		return fmt.Errorf("unrecognized prefix: %s", key)
+	}
+	if key == "ln" && code == "target" {
+		c.linkAsTarget = true
+		delete(c.types, key)
+		return nil
+	}
+	if key == "ln" {
+		c.linkAsTarget = false
+	}
+	c.types[key] = code
+	return nil
+}
+
+// isColorKey reports whether key is one of the two-letter keys of LS_COLORS.
+func isColorKey(key string) bool {
+	for _, k := range colorKeys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// scanColorString reads a key or a code of LS_COLORS starting at s[i],
+// turning its escapes into the characters they stand for. It stops at an
+// unescaped colon, or at an equals sign when reading a key, and returns
+// the position it stopped at.
+func scanColorString(s string, i int, key bool) (string, int, error) {
+	var b strings.Builder
+	for i < len(s) {
+		switch c := s[i]; {
+		case c == ':' || (key && c == '='):
+			return b.String(), i, nil
+		case c == '\\':
+			i++
+			if i == len(s) {
+				return "", i, fmt.Errorf("trailing backslash")
+			}
+			switch e := s[i]; {
+			case e >= '0' && e <= '7':
+				n := 0
+				for j := 0; j < 3 && i < len(s) && s[i] >= '0' && s[i] <= '7'; j++ {
+					n = n*8 + int(s[i]-'0')
+					i++
+				}
+				b.WriteByte(byte(n))
+				continue
+			case e == 'x' || e == 'X':
+				n, digits := 0, 0
+				for i++; digits < 2 && i < len(s) && isHexDigit(s[i]); i++ {
+					n = n*16 + hexValue(s[i])
+					digits++
+				}
+				b.WriteByte(byte(n))
+				continue
+			default:
+				b.WriteByte(unescapeColorByte(e))
+			}
+			i++
+		case c == '^':
+			i++
+			if i == len(s) {
+				return "", i, fmt.Errorf("trailing caret")
+			}
+			switch e := s[i]; {
+			case e == '?':
+				b.WriteByte(0x7f)
+			case e >= '@' && e <= '~':
+				b.WriteByte(e & 0x1f)
+			default:
+				return "", i, fmt.Errorf("invalid caret escape ^%c", e)
+			}
+			i++
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String(), i, nil
+}
+
+// unescapeColorByte returns the character written as a backslash followed by e.
+func unescapeColorByte(e byte) byte {
+	switch e {
+	case 'a':
+		return '\a'
+	case 'b':
+		return '\b'
+	case 'e':
+		return 0x1b
+	case 'f':
+		return '\f'
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'v':
+		return '\v'
+	case '?':
+		return 0x7f
+	case '_':
+		return ' '
+	}
+	return e
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c|0x20 >= 'a' && c|0x20 <= 'f')
+}
+
+func hexValue(c byte) int {
+	if isDigit(c) {
+		return int(c - '0')
+	}
+	return int(c|0x20-'a') + 10
+}
+
+// escapeColorString writes s so that scanColorString reads it back.
+func escapeColorString(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == 0x1b:
+			b.WriteString(`\e`)
+		case c == '\\' || c == ':' || c == '=' || c == '^':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&b, `\%03o`, c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// String writes the scheme in the format of LS_COLORS.
+func (c *ColorScheme) String() string {
+	var b strings.Builder
+	for _, item := range c.items() {
+		b.WriteString(escapeColorString(item.key) + "=" + escapeColorString(item.code) + ":")
+	}
+	return b.String()
+}
+
+// colorItem is a key of the scheme along with its code.
+type colorItem struct {
+	key, code string
+}
+
+// items returns the keys of the scheme with their codes: the kinds of
+// file in the usual LS_COLORS order, then the suffix rules as given.
+func (c *ColorScheme) items() []colorItem {
+	var items []colorItem
+	for _, key := range colorKeys {
+		if code, ok := c.types[key]; ok {
+			items = append(items, colorItem{key, code})
+		}
+		if key == "ln" && c.linkAsTarget {
+			items = append(items, colorItem{key, "target"})
+		}
+	}
+	for _, ext := range c.exts {
+		items = append(items, colorItem{"*" + ext.suffix, ext.code})
+	}
+	return items
+}
+
+// PrintColors writes every key of the Lister's colour scheme on its own
+// line, each one shown in the colour it stands for.
+func (l *Lister) PrintColors() {
+	c := l.colors()
+	for _, item := range c.items() {
+		line := item.key + "=" + escapeColorString(item.code)
+		switch item.key {
+		case "lc", "rc", "ec", "rs", "cl":
+		default:
+			if item.code != "target" {
+				line = c.sequence(item.code) + line + c.reset()
+			}
+		}
+		fmt.Fprintln(l.Stdout, line)
+	}
+}
+
+// colors returns the Lister's colour scheme, falling back to the built-in
+// one, which is built once for the Lister.
+func (l *Lister) colors() *ColorScheme {
+	if l.Colors == nil {
+		l.colorsOnce.Do(func() { l.defaultColors = DefaultColors() })
+		return l.defaultColors
+	}
+	return l.Colors
+}
+
+// sequence returns the escape sequence that starts writing in code.
+func (c *ColorScheme) sequence(code string) string {
+	return c.types["lc"] + code + c.types["rc"]
+}
+
+// reset returns the escape sequence that ends a coloured name.
+func (c *ColorScheme) reset() string {
+	if ec, ok := c.types["ec"]; ok {
+		return ec
+	}
+	return c.sequence(c.types["rs"])
+}
+
+// code returns the code a name is written in, or "" when it is left as is.
+// kind is the two-letter key of the type of file given by fileType; it is
+// refined with the permission bits of mode, as GNU ls does, and regular
+// files without a more specific colour are matched against the suffix rules.
+func (c *ColorScheme) code(kind, mode, name, linkCount string) string {
+	has := func(key string) bool { return c.types[key] != "" }
+	switch kind {
+	case "fi":
+		switch {
+		case has("su") && (mode[3] == 's' || mode[3] == 'S'):
+			return c.types["su"]
+		case has("sg") && (mode[6] == 's' || mode[6] == 'S'):
+			return c.types["sg"]
+		case has("ex") && isExecutable(mode):
+			return c.types["ex"]
+		case has("mh") && linkCount != "" && linkCount != "1":
+			return c.types["mh"]
+		}
+		if code, ok := c.extCode(name); ok {
+			return code
+		}
+	case "di":
+		sticky := mode[9] == 't' || mode[9] == 'T'
+		otherWritable := mode[8] == 'w'
+		switch {
+		case has("tw") && sticky && otherWritable:
+			return c.types["tw"]
+		case has("ow") && otherWritable:
+			return c.types["ow"]
+		case has("st") && sticky:
+			return c.types["st"]
+		}
+	}
+	return c.types[kind]
+}
+
+// extCode returns the code of the last suffix rule matching name. Like
+// GNU ls, suffixes are matched regardless of case.
+func (c *ColorScheme) extCode(name string) (string, bool) {
+	lower := strings.ToLower(name)
+	for i := len(c.exts) - 1; i >= 0; i-- {
+		if strings.HasSuffix(lower, strings.ToLower(c.exts[i].suffix)) {
+			return c.exts[i].code, true
+		}
+	}
+	return "", false
+}
+
+// isExecutable reports whether any of the execute bits of a mode string such
+// as "-rwxr-xr-x" is set. Lowercase "s" and "t" stand for an execute bit too.
+func isExecutable(mode string) bool {
+	for _, i := range []int{3, 6, 9} {
+		if mode[i] == 'x' || mode[i] == 's' || mode[i] == 't' {
+			return true
+		}
+	}
+	return false
+}
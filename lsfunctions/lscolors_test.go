@@ -0,0 +1,91 @@
+package lsfunctions
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseLSColors(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{name: "test 1", value: "di=01;34:ln=01;36", key: "ln", want: "01;36"},
+		{name: "test 2", value: "di=01;34:", key: "ex", want: defaultColorTypes["ex"]},
+		{name: "test 3", value: "lc=\\e[:rc=^[m", key: "rc", want: "\033m"},
+		{name: "test 4", value: "ec=\\x1b[0m\\_", key: "ec", want: "\033[0m "},
+		{name: "test 5", value: "*a\\:b=01:fi=00", key: "*a:b", want: "01"},
+		{name: "test 6", value: "xx=01", wantErr: true},
+		{name: "test 7", value: "di", wantErr: true},
+		{name: "test 8", value: "*.tar", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLSColors(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLSColors() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			for _, item := range got.items() {
+				if item.key == tt.key {
+					if item.code != tt.want {
+						t.Errorf("ParseLSColors() %s = %q, want %q", tt.key, item.code, tt.want)
+					}
+					return
+				}
+			}
+			t.Errorf("ParseLSColors() has no %s", tt.key)
+		})
+	}
+}
+
+func TestColorScheme_String(t *testing.T) {
+	colors, err := ParseLSColors("rs=0:di=01;34:ln=target:*.tar=01;31:*.tgz=01;31")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "lc=\\e[:rc=m:rs=0:di=01;34:ln=target:pi=38;2;162;115;76;40:so=1;38;2;163;71;181:" +
+		"bd=1;38;2;162;115;76;40:cd=1;38;2;162;115;76;40:or=40;31;01:ex=1;38;2;39;169;105:su=48;2;192;28;20:" +
+		"sg=48;2;162;115;76;30:st=42;30:*.tar=01;31:*.tgz=01;31:"
+	if got := colors.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	again, err := ParseLSColors(colors.String())
+	if err != nil || again.String() != want {
+		t.Errorf("ParseLSColors(String()) = %v, %v", again, err)
+	}
+}
+
+func TestLister_PrintColors(t *testing.T) {
+	colors := &ColorScheme{
+		types: map[string]string{"lc": "\033[", "rc": "m", "di": "01;34"},
+		exts:  []extColor{{suffix: ".tar", code: "01;31"}},
+	}
+	var out bytes.Buffer
+	l := &Lister{Stdout: &out, Colors: colors}
+	l.PrintColors()
+	want := "lc=\\e[\nrc=m\n\033[01;34mdi=01;34\033[m\n\033[01;31m*.tar=01;31\033[m\n"
+	if got := out.String(); got != want {
+		t.Errorf("PrintColors() = %q, want %q", got, want)
+	}
+}
+
+func TestLister_colors(t *testing.T) {
+	l := &Lister{}
+	got := l.colors()
+	if want := DefaultColors().String(); got.String() != want {
+		t.Errorf("colors() = %q, want %q", got.String(), want)
+	}
+	if again := l.colors(); again != got {
+		t.Errorf("colors() built the default scheme again")
+	}
+	colors := &ColorScheme{types: map[string]string{"di": "01;34"}}
+	if got := (&Lister{Colors: colors}).colors(); got != colors {
+		t.Errorf("colors() = %p, want the Lister's scheme %p", got, colors)
+	}
+}
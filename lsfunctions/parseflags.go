@@ -1,6 +1,11 @@
 package lsfunctions
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
 
 // Flag struct to store parsed flag and its value
 type Flags struct {
@@ -8,53 +13,569 @@ type Flags struct {
 	All       bool
 	Recursive bool
 	Reverse   bool
-	Time      bool
+	// Sort selects the order of the entries; by default they are sorted by name.
+	Sort SortKey
+	// TimeField selects the timestamp shown by -l and sorted on by -t.
+	TimeField TimeField
+	// TimeStyle is the argument of --time-style; empty means "locale".
+	TimeStyle string
+	// Layout of the short listing. When none is set, names are
+	// listed in columns filled down.
+	Columns    bool
+	Across     bool
+	OnePerLine bool
+	// JSON writes the listing as a JSON document instead of text.
+	JSON bool
+	// NDJSON streams one JSON record per entry, one per line.
+	NDJSON bool
+	// Archive lists tar, tar.gz and zip archives as if they were directories.
+	Archive bool
+	// Sizes are written in powers of 1024 with -h, in powers of 1000 with
+	// --si, or in units of BlockSize, such as "K" or "1MiB".
+	HumanReadable bool
+	SI            bool
+	BlockSize     string
+	// Width is the line width given with -w. Zero uses the width of the
+	// terminal, and a negative width means there is no limit.
+	Width int
+	// Color tells when names are coloured; by default only when writing
+	// to a terminal.
+	Color ColorWhen
+	// ColorsFile is a dircolors database to read the colour scheme from
+	// instead of LS_COLORS.
+	ColorsFile string
+	// NumericIDs shows the owner and group of files as numbers in the
+	// long format. NoOwner and NoGroup leave out their columns, and Author
+	// adds the author's, which on Linux is the owner.
+	NumericIDs bool
+	NoOwner    bool
+	NoGroup    bool
+	Author     bool
+	// Inode and Size start every entry with its inode number and its
+	// allocated size, written in the units of BlockSize.
+	Inode bool
+	Size  bool
+	// Indicator selects the characters appended to names to tell their type.
+	Indicator IndicatorStyle
+	// Dereference tells which symbolic links are followed, listing the
+	// files they point to in their place.
+	Dereference Dereference
+	// FindBroken lists only the symbolic links that lead to no file. With
+	// Recursive, every subdirectory is still searched.
+	FindBroken bool
+	// Quoting selects how names are quoted. By default they are quoted for
+	// the shell when writing to a terminal and written as they are otherwise.
+	Quoting QuotingStyle
+	// ControlChars tells whether nonprintable characters of names are
+	// shown as "?"; by default they are when writing to a terminal.
+	ControlChars ControlChars
+	// Jobs bounds the goroutines that read the directories of a listing
+	// and gather the details of their files, all of them together, besides
+	// the goroutine writing the listing; zero uses one per CPU.
+	Jobs int
+	// MaxOpenFiles bounds the number of directories read at once; zero
+	// allows one per job. As they are read by the goroutines Jobs counts,
+	// a value above Jobs has the effect of Jobs.
+	MaxOpenFiles int
+	// PrintColors asks for the colour scheme to be printed instead of a listing.
+	PrintColors bool
+	// Help and Version ask for the usage or the version to be printed
+	// instead of a listing.
+	Help    bool
+	Version bool
 }
 
-// parseFlags parses command-line arguments to extract flags and non-flag arguments.
-// It supports both long format (e.g., "--long") and short format (e.g., "-l") flags.
+// SortKey selects the order entries are listed in.
+type SortKey string
+
+const (
+	SortName      SortKey = "name"
+	SortNone      SortKey = "none"
+	SortSize      SortKey = "size"
+	SortTime      SortKey = "time"
+	SortVersion   SortKey = "version"
+	SortExtension SortKey = "extension"
+)
+
+// ColorWhen tells when names are coloured.
+type ColorWhen string
+
+const (
+	ColorAuto   ColorWhen = ""
+	ColorAlways ColorWhen = "always"
+	ColorNever  ColorWhen = "never"
+)
+
+// IndicatorStyle selects the characters appended to names to tell their type:
+// none, "/" for directories with slash, "/", "@", "|", "=" and ">" for the
+// other kinds of files with file-type, and "*" for executables on top with
+// classify.
+type IndicatorStyle string
+
+const (
+	IndicatorNone     IndicatorStyle = ""
+	IndicatorSlash    IndicatorStyle = "slash"
+	IndicatorFileType IndicatorStyle = "file-type"
+	IndicatorClassify IndicatorStyle = "classify"
+	// IndicatorClassifyAuto classifies names only when writing to a
+	// terminal, as --classify=auto does.
+	IndicatorClassifyAuto IndicatorStyle = "classify-auto"
+)
+
+// Dereference tells which symbolic links are followed. By default, the
+// links to directories given on the command line are followed, unless the
+// long format or the classify indicators are used.
+type Dereference string
+
+const (
+	DerefDefault Dereference = ""
+	// DerefNever follows no link. It is what the default becomes with the
+	// long format.
+	DerefNever Dereference = "never"
+	// DerefSymlinkToDir follows the links given on the command line that
+	// point to directories, as --dereference-command-line-symlink-to-dir does.
+	DerefSymlinkToDir Dereference = "command-line-symlink-to-dir"
+	// DerefCommandLine follows the links given on the command line, as -H does.
+	DerefCommandLine Dereference = "command-line"
+	// DerefAlways follows every link, as -L does, so -R descends into the
+	// directories they point to.
+	DerefAlways Dereference = "always"
+)
+
+// QuotingStyle selects how names are quoted. The empty style picks one
+// depending on the output.
+type QuotingStyle string
+
+const (
+	QuoteDefault           QuotingStyle = ""
+	QuoteLiteral           QuotingStyle = "literal"
+	QuoteLocale            QuotingStyle = "locale"
+	QuoteShell             QuotingStyle = "shell"
+	QuoteShellAlways       QuotingStyle = "shell-always"
+	QuoteShellEscape       QuotingStyle = "shell-escape"
+	QuoteShellEscapeAlways QuotingStyle = "shell-escape-always"
+	QuoteC                 QuotingStyle = "c"
+	QuoteEscape            QuotingStyle = "escape"
+)
+
+// ControlChars tells whether nonprintable characters of names are hidden.
+type ControlChars string
+
+const (
+	ControlAuto ControlChars = ""
+	ControlHide ControlChars = "hide"
+	ControlShow ControlChars = "show"
+)
+
+// TimeField selects which of a file's timestamps is used.
+type TimeField string
+
+const (
+	TimeModification TimeField = ""
+	TimeAccess       TimeField = "atime"
+	TimeChange       TimeField = "ctime"
+	TimeBirth        TimeField = "birth"
+)
+
+// argKind tells whether an option takes an argument.
+type argKind int
+
+const (
+	noArgument argKind = iota
+	requiredArgument
+	// optionalArgument is only taken by long names, as in "--color=never";
+	// the short name of such an option, as -F for --classify, takes none.
+	optionalArgument
+)
+
+// option describes a command-line option, known by a short name, a long name
+// or both. set applies the option to the flags, given its argument if it takes one.
+type option struct {
+	short byte
+	long  string
+	arg   argKind
+	set   func(flags *Flags, value string) error
+}
+
+// options is the table of options ParseFlags understands.
+var options = []option{
+	{short: 'l', set: func(f *Flags, _ string) error { return setFormat(f, "long") }},
+	{short: 'a', long: "all", set: func(f *Flags, _ string) error { f.All = true; return nil }},
+	{short: 'R', long: "recursive", set: func(f *Flags, _ string) error { f.Recursive = true; return nil }},
+	{short: 'r', long: "reverse", set: func(f *Flags, _ string) error { f.Reverse = true; return nil }},
+	{short: 't', set: func(f *Flags, _ string) error { f.Sort = SortTime; return nil }},
+	{short: 'S', set: func(f *Flags, _ string) error { f.Sort = SortSize; return nil }},
+	{short: 'X', set: func(f *Flags, _ string) error { f.Sort = SortExtension; return nil }},
+	{short: 'v', set: func(f *Flags, _ string) error { f.Sort = SortVersion; return nil }},
+	{short: 'U', set: func(f *Flags, _ string) error { f.Sort = SortNone; return nil }},
+	{short: 'f', set: func(f *Flags, _ string) error {
+		// Like GNU ls, -f also turns off -l, -s and --color given before it.
+		f.All, f.Long, f.Sort = true, false, SortNone
+		f.Size, f.Color = false, ColorNever
+		return nil
+	}},
+	{long: "sort", arg: requiredArgument, set: func(f *Flags, value string) error {
+		key, err := argmatch("--sort", value, sortChoices)
+		f.Sort = SortKey(key)
+		return err
+	}},
+	{short: 'u', set: func(f *Flags, _ string) error { f.TimeField = TimeAccess; return nil }},
+	{short: 'c', set: func(f *Flags, _ string) error { f.TimeField = TimeChange; return nil }},
+	{long: "time", arg: requiredArgument, set: func(f *Flags, value string) error {
+		field, err := argmatch("--time", value, timeChoices)
+		f.TimeField = TimeField(field)
+		return err
+	}},
+	{long: "time-style", arg: requiredArgument, set: func(f *Flags, value string) error {
+		if _, err := parseTimeStyle(value); err != nil {
+			return err
+		}
+		f.TimeStyle = value
+		return nil
+	}},
+	{long: "full-time", set: func(f *Flags, _ string) error {
+		f.TimeStyle = "full-iso"
+		return setFormat(f, "long")
+	}},
+	{short: 'C', set: func(f *Flags, _ string) error { return setFormat(f, "vertical") }},
+	{short: 'x', set: func(f *Flags, _ string) error { return setFormat(f, "across") }},
+	{short: '1', set: func(f *Flags, _ string) error {
+		// As in GNU ls, -1 has no effect after -l.
+		if f.Long {
+			return nil
+		}
+		return setFormat(f, "single-column")
+	}},
+	{long: "format", arg: requiredArgument, set: func(f *Flags, value string) error {
+		format, err := argmatch("--format", value, formatChoices)
+		if err != nil {
+			return err
+		}
+		return setFormat(f, format)
+	}},
+	{short: 'w', long: "width", arg: requiredArgument, set: func(f *Flags, value string) error {
+		width, err := strconv.Atoi(value)
+		if err != nil || width < 0 {
+			return fmt.Errorf("invalid line width: '%s'", value)
+		}
+		if width == 0 {
+			width = -1
+		}
+		f.Width = width
+		return nil
+	}},
+	{short: 'n', long: "numeric-uid-gid", set: func(f *Flags, _ string) error {
+		f.NumericIDs = true
+		return setFormat(f, "long")
+	}},
+	{short: 'g', set: func(f *Flags, _ string) error {
+		f.NoOwner = true
+		return setFormat(f, "long")
+	}},
+	{short: 'o', set: func(f *Flags, _ string) error {
+		f.NoGroup = true
+		return setFormat(f, "long")
+	}},
+	{short: 'G', long: "no-group", set: func(f *Flags, _ string) error { f.NoGroup = true; return nil }},
+	{long: "author", set: func(f *Flags, _ string) error { f.Author = true; return nil }},
+	{short: 'i', long: "inode", set: func(f *Flags, _ string) error { f.Inode = true; return nil }},
+	{short: 's', long: "size", set: func(f *Flags, _ string) error { f.Size = true; return nil }},
+	{short: 'h', long: "human-readable", set: func(f *Flags, _ string) error {
+		f.HumanReadable, f.SI, f.BlockSize = true, false, ""
+		return nil
+	}},
+	{long: "si", set: func(f *Flags, _ string) error {
+		f.HumanReadable, f.SI, f.BlockSize = false, true, ""
+		return nil
+	}},
+	{long: "block-size", arg: requiredArgument, set: func(f *Flags, value string) error {
+		if _, err := parseBlockSize(value); err != nil {
+			return err
+		}
+		f.HumanReadable, f.SI, f.BlockSize = false, false, value
+		return nil
+	}},
+	{long: "jobs", arg: requiredArgument, set: func(f *Flags, value string) error {
+		jobs, err := strconv.Atoi(value)
+		if err != nil || jobs < 1 {
+			return fmt.Errorf("invalid number of jobs: '%s'", value)
+		}
+		f.Jobs = jobs
+		return nil
+	}},
+	{long: "max-open-files", arg: requiredArgument, set: func(f *Flags, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number of open files: '%s'", value)
+		}
+		f.MaxOpenFiles = n
+		return nil
+	}},
+	{long: "ndjson", set: func(f *Flags, _ string) error { f.NDJSON = true; return nil }},
+	{long: "archive", set: func(f *Flags, _ string) error { f.Archive = true; return nil }},
+	{long: "color", arg: optionalArgument, set: func(f *Flags, value string) error {
+		if value == "" {
+			f.Color = ColorAlways
+			return nil
+		}
+		when, err := argmatch("--color", value, colorChoices)
+		f.Color = ColorWhen(when)
+		return err
+	}},
+	{short: 'F', long: "classify", arg: optionalArgument, set: func(f *Flags, value string) error {
+		when := string(ColorAlways)
+		if value != "" {
+			var err error
+			if when, err = argmatch("--classify", value, colorChoices); err != nil {
+				return err
+			}
+		}
+		switch ColorWhen(when) {
+		case ColorAlways:
+			f.Indicator = IndicatorClassify
+		case ColorNever:
+			f.Indicator = IndicatorNone
+		default:
+			f.Indicator = IndicatorClassifyAuto
+		}
+		return nil
+	}},
+	{long: "file-type", set: func(f *Flags, _ string) error { f.Indicator = IndicatorFileType; return nil }},
+	{short: 'p', set: func(f *Flags, _ string) error { f.Indicator = IndicatorSlash; return nil }},
+	{long: "indicator-style", arg: requiredArgument, set: func(f *Flags, value string) error {
+		style, err := argmatch("--indicator-style", value, indicatorChoices)
+		f.Indicator = IndicatorStyle(style)
+		return err
+	}},
+	{short: 'L', long: "dereference", set: func(f *Flags, _ string) error { f.Dereference = DerefAlways; return nil }},
+	{short: 'H', long: "dereference-command-line", set: func(f *Flags, _ string) error {
+		f.Dereference = DerefCommandLine
+		return nil
+	}},
+	{long: "dereference-command-line-symlink-to-dir", set: func(f *Flags, _ string) error {
+		f.Dereference = DerefSymlinkToDir
+		return nil
+	}},
+	{long: "find-broken", set: func(f *Flags, _ string) error { f.FindBroken = true; return nil }},
+	{short: 'N', long: "literal", set: func(f *Flags, _ string) error { f.Quoting = QuoteLiteral; return nil }},
+	{short: 'b', long: "escape", set: func(f *Flags, _ string) error { f.Quoting = QuoteEscape; return nil }},
+	{short: 'Q', long: "quote-name", set: func(f *Flags, _ string) error { f.Quoting = QuoteC; return nil }},
+	{long: "quoting-style", arg: requiredArgument, set: func(f *Flags, value string) error {
+		style, err := argmatch("--quoting-style", value, quotingChoices)
+		f.Quoting = QuotingStyle(style)
+		return err
+	}},
+	{short: 'q', long: "hide-control-chars", set: func(f *Flags, _ string) error { f.ControlChars = ControlHide; return nil }},
+	{long: "show-control-chars", set: func(f *Flags, _ string) error { f.ControlChars = ControlShow; return nil }},
+	{long: "dircolors", arg: requiredArgument, set: func(f *Flags, value string) error { f.ColorsFile = value; return nil }},
+	{long: "print-colors", set: func(f *Flags, _ string) error { f.PrintColors = true; return nil }},
+	{long: "help", set: func(f *Flags, _ string) error { f.Help = true; return nil }},
+	{long: "version", set: func(f *Flags, _ string) error { f.Version = true; return nil }},
+}
+
+// choice is one of the words an option argument may be, along with the
+// value it stands for. Synonyms share a value.
+type choice struct {
+	word, value string
+}
+
+var (
+	sortChoices = []choice{
+		{"none", string(SortNone)}, {"name", string(SortName)}, {"size", string(SortSize)},
+		{"time", string(SortTime)}, {"version", string(SortVersion)}, {"extension", string(SortExtension)},
+	}
+	timeChoices = []choice{
+		{"atime", string(TimeAccess)}, {"access", string(TimeAccess)}, {"use", string(TimeAccess)},
+		{"ctime", string(TimeChange)}, {"status", string(TimeChange)},
+		{"birth", string(TimeBirth)}, {"creation", string(TimeBirth)},
+		{"mtime", string(TimeModification)}, {"modification", string(TimeModification)},
+	}
+	colorChoices = []choice{
+		{"always", string(ColorAlways)}, {"yes", string(ColorAlways)}, {"force", string(ColorAlways)},
+		{"never", string(ColorNever)}, {"no", string(ColorNever)}, {"none", string(ColorNever)},
+		{"auto", string(ColorAuto)}, {"tty", string(ColorAuto)}, {"if-tty", string(ColorAuto)},
+	}
+	indicatorChoices = []choice{
+		{"none", string(IndicatorNone)}, {"slash", string(IndicatorSlash)},
+		{"file-type", string(IndicatorFileType)}, {"classify", string(IndicatorClassify)},
+	}
+	quotingChoices = []choice{
+		{"literal", string(QuoteLiteral)}, {"locale", string(QuoteLocale)},
+		{"shell", string(QuoteShell)}, {"shell-always", string(QuoteShellAlways)},
+		{"shell-escape", string(QuoteShellEscape)}, {"shell-escape-always", string(QuoteShellEscapeAlways)},
+		{"c", string(QuoteC)}, {"escape", string(QuoteEscape)},
+	}
+	formatChoices = []choice{
+		{"verbose", "long"}, {"long", "long"},
+		{"across", "across"}, {"horizontal", "across"},
+		{"vertical", "vertical"}, {"single-column", "single-column"},
+		{"json", "json"},
+	}
+)
+
+// tryHelp ends the message of a usage error, as GNU ls does.
+const tryHelp = "\nTry 'ls --help' for more information."
+
+// ParseFlags parses command-line arguments to extract flags and non-flag arguments.
+// It follows the conventions of GNU ls: short options may be grouped as in
+// "-la", long options may be abbreviated to any unambiguous prefix, and an
+// option's argument may follow it directly ("-w80", "--sort=size") or come
+// as the next argument ("-w 80", "--sort size"). Options and operands may be
+// mixed, a lone "-" is an operand and "--" ends the options.
 //
 // Parameters:
 //   - args: A slice of strings representing the command-line arguments to be parsed.
 //
 // Returns:
-//   - flags: A Flags struct containing boolean values for each recognized flag.
+//   - flags: A Flags struct holding the options that were given.
 //   - parsedArgs: A slice of strings containing the non-flag arguments.
+//   - err: A usage error, worded like the ones of GNU ls.
+//
+// Parsing stops at --help or --version, which take precedence over the rest.
 func ParseFlags(args []string) (flags Flags, parsedArgs []string, err error) {
-	for _, arg := range args {
-		if arg[0] == '-' {
-			if len(arg) > 1 {
-				switch arg {
-				case "--reverse":
-					flags.Reverse = true
-				case "--all":
-					flags.All = true
-				case "--recursive":
-					flags.Recursive = true
-				default:
-					for _, flag := range arg[1:] {
-						switch flag {
-						case 'l':
-							flags.Long = true
-						case 'a':
-							flags.All = true
-						case 'R':
-							flags.Recursive = true
-						case 't':
-							flags.Time = true
-						case 'r':
-							flags.Reverse = true
-						default:
-							return Flags{}, nil, fmt.Errorf("invalid option -- '%s'\nTry 'ls --help' for more information", arg[1:])
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return flags, append(parsedArgs, args[i+1:]...), nil
+		case strings.HasPrefix(arg, "--"):
+			name, value, hasValue := strings.Cut(arg[2:], "=")
+			opt, err := lookupLong(name, arg)
+			if err != nil {
+				return Flags{}, nil, err
+			}
+			if opt.arg == noArgument && hasValue {
+				return Flags{}, nil, fmt.Errorf("option '--%s' doesn't allow an argument"+tryHelp, opt.long)
+			}
+			if opt.arg == requiredArgument && !hasValue {
+				if i+1 == len(args) {
+					return Flags{}, nil, fmt.Errorf("option '--%s' requires an argument"+tryHelp, opt.long)
+				}
+				i++
+				value = args[i]
+			}
+			if err := opt.set(&flags, value); err != nil {
+				return Flags{}, nil, err
+			}
+		case len(arg) > 1 && arg[0] == '-':
+			for j := 1; j < len(arg); j++ {
+				opt, ok := lookupShort(arg[j])
+				if !ok {
+					return Flags{}, nil, fmt.Errorf("invalid option -- '%c'"+tryHelp, arg[j])
+				}
+				var value string
+				if opt.arg == requiredArgument {
+					value, j = arg[j+1:], len(arg)
+					if value == "" {
+						if i+1 == len(args) {
+							return Flags{}, nil, fmt.Errorf("option requires an argument -- '%c'"+tryHelp, opt.short)
 						}
+						i++
+						value = args[i]
 					}
 				}
-			} else {
-				return Flags{}, nil, fmt.Errorf("cannot access '%s': No such file or directory", arg)
-			} 
-		} else {
+				if err := opt.set(&flags, value); err != nil {
+					return Flags{}, nil, err
+				}
+			}
+		default:
 			parsedArgs = append(parsedArgs, arg)
 		}
+		if flags.Help || flags.Version {
+			return flags, nil, nil
+		}
 	}
 	return flags, parsedArgs, nil
 }
+
+// lookupShort finds the option with the given short name.
+func lookupShort(c byte) (option, bool) {
+	for _, opt := range options {
+		if opt.short == c {
+			return opt, true
+		}
+	}
+	return option{}, false
+}
+
+// lookupLong finds the option whose long name is name or, failing that,
+// the only one that starts with it. arg is the argument as given, for errors.
+func lookupLong(name, arg string) (option, error) {
+	var matches []option
+	for _, opt := range options {
+		if opt.long == "" || !strings.HasPrefix(opt.long, name) {
+			continue
+		}
+		if opt.long == name {
+			return opt, nil
+		}
+		matches = append(matches, opt)
+	}
+	switch len(matches) {
+	case 0:
+		return option{}, fmt.Errorf("unrecognized option '%s'"+tryHelp, arg)
+	case 1:
+		return matches[0], nil
+	}
+	var possibilities strings.Builder
+	for _, opt := range matches {
+		fmt.Fprintf(&possibilities, " '--%s'", opt.long)
+	}
+	return option{}, fmt.Errorf("option '--%s' is ambiguous; possibilities:%s"+tryHelp, name, possibilities.String())
+}
+
+// argmatch returns the value of the choice named by arg, which may be
+// abbreviated as long as it does not stand for several different values.
+// Otherwise it returns an error listing the valid arguments of the option.
+func argmatch(option, arg string, choices []choice) (string, error) {
+	matched, found, ambiguous := "", false, false
+	for _, c := range choices {
+		if c.word == arg {
+			return c.value, nil
+		}
+		if arg == "" || !strings.HasPrefix(c.word, arg) {
+			continue
+		}
+		if found && c.value != matched {
+			ambiguous = true
+		}
+		matched, found = c.value, true
+	}
+	if found && !ambiguous {
+		return matched, nil
+	}
+	problem := "invalid"
+	if ambiguous {
+		problem = "ambiguous"
+	}
+	var valid strings.Builder
+	for i, c := range choices {
+		if i > 0 && choices[i-1].value == c.value {
+			fmt.Fprintf(&valid, ", '%s'", c.word)
+			continue
+		}
+		fmt.Fprintf(&valid, "\n  - '%s'", c.word)
+	}
+	return "", fmt.Errorf("%s argument '%s' for '%s'\nValid arguments are:%s"+tryHelp, problem, arg, option, valid.String())
+}
+
+// setFormat applies a listing format, named as for --format, to flags.
+// It replaces the format chosen before, so the last one given wins.
+func setFormat(flags *Flags, format string) error {
+	flags.Long, flags.Columns, flags.Across, flags.OnePerLine, flags.JSON = false, false, false, false, false
+	switch format {
+	case "long":
+		flags.Long = true
+	case "vertical":
+		flags.Columns = true
+	case "across":
+		flags.Across = true
+	case "single-column":
+		flags.OnePerLine = true
+	case "json":
+		flags.JSON = true
+	default:
+		return errors.New("unknown format " + format)
+	}
+	return nil
+}
@@ -15,6 +15,37 @@ func TestParseFlags(t *testing.T) {
 		{name: "test with no flags", args: []string{"file1", "file2"}, wantFlags: Flags{}, wantParsedArgs: []string{"file1", "file2"}},
 		{name: "test with single flag", args: []string{"-l", "file1", "file2"}, wantFlags: Flags{Long: true}, wantParsedArgs: []string{"file1", "file2"}},
 		{name: "test with multiple flags", args: []string{"-laR", "file1", "file2"}, wantFlags: Flags{Long: true, All: true, Recursive: true}, wantParsedArgs: []string{"file1", "file2"}},
+		{name: "test with sort keys", args: []string{"-tS", "--sort=version"}, wantFlags: Flags{Sort: SortVersion}},
+		{name: "test with json format", args: []string{"--format=json", "-R"}, wantFlags: Flags{JSON: true, Recursive: true}},
+		{name: "test with time fields", args: []string{"-lu", "-c"}, wantFlags: Flags{Long: true, TimeField: TimeChange}},
+		{name: "test with full time", args: []string{"--full-time"}, wantFlags: Flags{Long: true, TimeStyle: "full-iso"}},
+		{name: "test with time style", args: []string{"--time-style=+%Y", "-l"}, wantFlags: Flags{Long: true, TimeStyle: "+%Y"}},
+		{name: "test with dash and empty operands", args: []string{"-", "", "-a"}, wantFlags: Flags{All: true}, wantParsedArgs: []string{"-", ""}},
+		{name: "test with end of options", args: []string{"-l", "--", "-a", "--all"}, wantFlags: Flags{Long: true}, wantParsedArgs: []string{"-a", "--all"}},
+		{name: "test with attached value", args: []string{"-lw80", "--sort=ext"}, wantFlags: Flags{Long: true, Width: 80, Sort: SortExtension}},
+		{name: "test with separate value", args: []string{"-w", "0", "--sort", "size", "dir"}, wantFlags: Flags{Width: -1, Sort: SortSize}, wantParsedArgs: []string{"dir"}},
+		{name: "test with abbreviations", args: []string{"--rec", "--hum", "--time=acc"}, wantFlags: Flags{Recursive: true, HumanReadable: true, TimeField: TimeAccess}},
+		{name: "test with color", args: []string{"--color", "--colo=never", "--color=if-tty", "--color"}, wantFlags: Flags{Color: ColorAlways}},
+		{name: "test with help", args: []string{"-l", "--help", "-z"}, wantFlags: Flags{Long: true, Help: true}},
+		{name: "test with owner columns", args: []string{"-n", "-goG", "--author"}, wantFlags: Flags{Long: true, NumericIDs: true, NoOwner: true, NoGroup: true, Author: true}},
+		{name: "test with no group", args: []string{"--no-g"}, wantFlags: Flags{NoGroup: true}},
+		{name: "test with indicators", args: []string{"-F", "--file-type", "-p"}, wantFlags: Flags{Indicator: IndicatorSlash}},
+		{name: "test with classify when", args: []string{"--classify=if-tty", "--indicator-style=none", "--class"}, wantFlags: Flags{Indicator: IndicatorClassify}},
+		{name: "test with classify auto", args: []string{"-pF", "--classify=auto"}, wantFlags: Flags{Indicator: IndicatorClassifyAuto}},
+		{name: "test with quoting styles", args: []string{"-Q", "-b", "--quoting=shell-escape"}, wantFlags: Flags{Quoting: QuoteShellEscape}},
+		{name: "test with control chars", args: []string{"-qN", "--show-control-chars", "-q"}, wantFlags: Flags{Quoting: QuoteLiteral, ControlChars: ControlHide}},
+		{name: "test with dereference", args: []string{"-L", "-H"}, wantFlags: Flags{Dereference: DerefCommandLine}},
+		{name: "test with dereference to dir", args: []string{"-lL", "--dereference-command-line-s"}, wantFlags: Flags{Long: true, Dereference: DerefSymlinkToDir}},
+		{name: "test with find broken", args: []string{"--find", "-R"}, wantFlags: Flags{FindBroken: true, Recursive: true}},
+		{name: "test with jobs", args: []string{"--jobs", "4", "--jobs=16"}, wantFlags: Flags{Jobs: 16}},
+		{name: "test with max open files", args: []string{"--max-open", "8"}, wantFlags: Flags{MaxOpenFiles: 8}},
+		{name: "test with long then columns", args: []string{"-l", "-C"}, wantFlags: Flags{Columns: true}},
+		{name: "test with columns then long", args: []string{"-x", "-l"}, wantFlags: Flags{Long: true}},
+		{name: "test with last format", args: []string{"--format=json", "-l", "--format=across"}, wantFlags: Flags{Across: true}},
+		{name: "test with one per line after long", args: []string{"-l1"}, wantFlags: Flags{Long: true}},
+		{name: "test with unsorted all", args: []string{"-ls", "--color=always", "-f"}, wantFlags: Flags{All: true, Sort: SortNone, Color: ColorNever}},
+		{name: "test with size after unsorted all", args: []string{"-f", "-s", "--color"}, wantFlags: Flags{All: true, Sort: SortNone, Size: true, Color: ColorAlways}},
+		{name: "test with time word", args: []string{"--time=creation", "dir"}, wantFlags: Flags{TimeField: TimeBirth}, wantParsedArgs: []string{"dir"}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -28,3 +59,32 @@ func TestParseFlags(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFlags_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "test 1", args: []string{"-lz"}, wantErr: "invalid option -- 'z'\nTry 'ls --help' for more information."},
+		{name: "test 2", args: []string{"--bogus=1"}, wantErr: "unrecognized option '--bogus=1'\nTry 'ls --help' for more information."},
+		{name: "test 3", args: []string{"--s"}, wantErr: "option '--s' is ambiguous; possibilities: '--sort' '--size' '--si' '--show-control-chars'\nTry 'ls --help' for more information."},
+		{name: "test 4", args: []string{"--recursive=yes"}, wantErr: "option '--recursive' doesn't allow an argument\nTry 'ls --help' for more information."},
+		{name: "test 5", args: []string{"--sort"}, wantErr: "option '--sort' requires an argument\nTry 'ls --help' for more information."},
+		{name: "test 6", args: []string{"-w"}, wantErr: "option requires an argument -- 'w'\nTry 'ls --help' for more information."},
+		{name: "test 7", args: []string{"-w", "wide"}, wantErr: "invalid line width: 'wide'"},
+		{name: "test 8", args: []string{"--time=c"}, wantErr: "ambiguous argument 'c' for '--time'\nValid arguments are:\n" +
+			"  - 'atime', 'access', 'use'\n  - 'ctime', 'status'\n  - 'birth', 'creation'\n  - 'mtime', 'modification'\n" +
+			"Try 'ls --help' for more information."},
+		{name: "test 9", args: []string{"--jobs=0"}, wantErr: "invalid number of jobs: '0'"},
+		{name: "test 10", args: []string{"--max-open-files=many"}, wantErr: "invalid number of open files: 'many'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := ParseFlags(tt.args)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ParseFlags() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
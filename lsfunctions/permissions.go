@@ -5,9 +5,11 @@ import (
 	"syscall"
 )
 
-func formatPermissionsWithACL(path string, mode os.FileMode) (string, error) {
+// formatPermissionsWithACL formats the mode like formatPermissions and appends
+// a "+" when the file system reports extended attributes for path.
+func formatPermissionsWithACL(fsys fileSystem, path string, mode os.FileMode) (string, error) {
 	permissions := formatPermissions(mode)
-	hasACL, err := hasExtendedAttributes(path)
+	hasACL, err := fsys.HasExtendedAttributes(path)
 
 	if err != nil {
 		return permissions, nil
@@ -3,74 +3,121 @@ package lsfunctions
 import (
 	"fmt"
 	"os"
-	"os/user"
-	"strconv"
-	"syscall"
 )
 
 // prepareFileDetailsForDisplay converts a list of FileDetails into a list of Entry.
-// If the file is a device file, is also gets the major and minor device numbers from the Rdev field.
-// It also adds quotes around the file names if they contain spaces.
-// It also gets the user and group names from the owner and group fields.
-func prepareFileDetailsForDisplay(entries []FileDetails) []Entry {
-	var formattedEntries []Entry
-	for _, entry := range entries {
+// If the file is a device file, it gets the major and minor device numbers from the Rdev field instead of the size.
+// It also quotes the file names following the quoting style, and finds their indicators.
+// It also gets the user and group names from the owner and group fields;
+// files without stat data get a link count of 1 and "?" for their owner,
+// group and, when it is not recorded, their time.
+// The entries are converted concurrently, on up to jobs goroutines, and
+// returned in the order they were given.
+func (l *Lister) prepareFileDetailsForDisplay(entries []FileDetails) []Entry {
+	now := l.now()
+	fsys := l.fsys()
+	sizeFormat, blockFormat := l.sizeFormats()
+	timeStyle := l.timeStyle()
+	indicatorStyle := l.indicatorStyle()
+	// Each entry takes system calls and user and group lookups, which
+	// are done on several goroutines, each one filling in its own slot.
+	formattedEntries := make([]Entry, len(entries))
+	l.pool().forEach(len(entries), func(i int) {
+		entry := entries[i]
 		var f Entry
 		info := entry.Info
 		mode := info.Mode()
-		f.Name = addQuotes(entry.Name)
-		f.Mode, _ = formatPermissionsWithACL(entry.Path, mode)
+		f.Name = l.quoteFile(entry.Name)
+		f.RawName = entry.Name
+		f.Mode, _ = formatPermissionsWithACL(fsys, entry.Path, mode)
+		f.Indicator = getIndicator(f.Mode, indicatorStyle)
 		f.Path = entry.Path
 		f.IsDirectory = info.IsDir()
 		f.LinkTarget = entry.LinkTarget
-		// f.IsBrokenLink = entry.IsBrokenLink
-		f.Time = formatTime(info.ModTime())
+		f.IsBrokenLink = entry.IsBrokenLink
+		f.TargetInfo = entry.TargetInfo
+		f.Time = "-"
+		if t, ok := entryTime(entry, l.Flags.TimeField); ok {
+			f.Time = timeStyle.format(t, now)
+			if t.IsZero() {
+				// A file system that records no times, such as an fs.FS.
+				f.Time = "?"
+			}
+		}
 		// Get size string
-		f.Size = fmt.Sprintf("%d", info.Size())
+		f.Size = sizeFormat.format(uint64(info.Size()))
+		stat, hasStat := fileStat(info)
 		if mode&os.ModeDevice != 0 {
-			if stat, ok := entry.Info.Sys().(*syscall.Stat_t); ok {
+			if hasStat {
 				entry.Rdev = stat.Rdev
 			}
-			major := major(entry.Rdev)
-			minor := minor(entry.Rdev)
-			f.Size = fmt.Sprintf("%d", major)
-			f.Minor = fmt.Sprintf("%d,", minor)
+			f.Major = fmt.Sprintf("%d", major(entry.Rdev))
+			f.Minor = fmt.Sprintf("%d", minor(entry.Rdev))
+			f.Size = ""
 		}
-		var owner, group string
-
-		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
-			uid := stat.Uid
-			gid := stat.Gid
-			f.LinkCount = fmt.Sprintf("%d", stat.Nlink)
-			owner = strconv.FormatUint(uint64(uid), 10)
-			group = strconv.FormatUint(uint64(gid), 10)
-		} else {
-			fmt.Printf("error getting syscall info")
+		if l.Flags.Inode {
+			f.Inode = "?"
+			if hasStat && stat.Ino != 0 {
+				f.Inode = fmt.Sprintf("%d", stat.Ino)
+			}
 		}
-		if u, err := user.LookupId(owner); err == nil {
-			f.Owner = u.Username
+		if l.Flags.Size {
+			f.Blocks = "?"
+			if hasStat {
+				f.Blocks = blockFormat.format(uint64(stat.Blocks) * 512)
+			}
 		}
-		if g, err := user.LookupGroupId(group); err == nil {
-			f.Group = g.Name
+		if hasStat {
+			f.LinkCount = fmt.Sprintf("%d", stat.Nlink)
+			l.setOwners(&f, stat)
+		} else {
+			// Without stat data, as with an fs.FS, the file has its single
+			// name and an unknown owner, keeping every row well formed.
+			f.LinkCount, f.Owner, f.Group = "1", "?", "?"
 		}
 
-		formattedEntries = append(formattedEntries, f)
+		formattedEntries[i] = f
+	})
+	if l.alignQuotes() {
+		padUnquoted(formattedEntries)
 	}
 	return formattedEntries
 }
 
 // getWidths calculates the maximum width for each column in the long format output.
-// It considers the mode, link count, owner, group, size, minor, and time columns.
+// It considers the inode, blocks, mode, link count, owner, group, size, major, minor, and time columns.
+// Columns are measured in terminal columns with displayWidth, so owner and
+// group names, and the month names of a time style, in any script line up.
 func getWidths(entries []Entry) Widths {
 	var w Widths
 	for _, f := range entries {
-		w.modCol = getMax(w.modCol, len(f.Mode))
-		w.groupCol = getMax(w.groupCol, len(f.Group))
-		w.ownerCol = getMax(w.ownerCol, len(f.Owner))
-		w.sizeCol = getMax(w.sizeCol, len(f.Size))
-		w.minorCol = getMax(w.minorCol, len(f.Minor))
-		w.timeCol = getMax(w.timeCol, len(f.Time))
-		w.linkCol = getMax(w.linkCol, len(f.LinkCount))
+		w.modCol = getMax(w.modCol, displayWidth(f.Mode))
+		w.groupCol = getMax(w.groupCol, displayWidth(f.Group))
+		w.ownerCol = getMax(w.ownerCol, displayWidth(f.Owner))
+		w.sizeCol = getMax(w.sizeCol, displayWidth(f.Size))
+		w.majorCol = getMax(w.majorCol, displayWidth(f.Major))
+		w.minorCol = getMax(w.minorCol, displayWidth(f.Minor))
+		w.timeCol = getMax(w.timeCol, displayWidth(f.Time))
+		w.linkCol = getMax(w.linkCol, displayWidth(f.LinkCount))
+		w.inodeCol = getMax(w.inodeCol, displayWidth(f.Inode))
+		w.blocksCol = getMax(w.blocksCol, displayWidth(f.Blocks))
+	}
+	if w.majorCol != 0 {
+		// Devices show "major, minor" in the size column.
+		w.sizeCol = getMax(w.sizeCol, w.majorCol+2+w.minorCol)
 	}
 	return w
 }
+
+// numberColumns returns the inode number and allocated size of an entry,
+// as shown before it with -i and -s, each right-aligned and followed by a space.
+func (l *Lister) numberColumns(e Entry, w Widths) string {
+	s := ""
+	if l.Flags.Inode {
+		s += padLeft(e.Inode, w.inodeCol) + " "
+	}
+	if l.Flags.Size {
+		s += padLeft(e.Blocks, w.blocksCol) + " "
+	}
+	return s
+}
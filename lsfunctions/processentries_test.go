@@ -1,6 +1,7 @@
 package lsfunctions
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 )
@@ -12,9 +13,9 @@ func Test_processEntries(t *testing.T) {
 		{Name: "go.mod", Info: mockFileInfo{name: "go.mod", mode: 0o644, size: 7}},
 	}
 	newEntries := []Entry{
-		{Name: "main.go", Mode: "-rw-r--r--", Size: "4", Time: "Jan  1  0001"},
-		{Name: "ted", Mode: "-rwxr-xr-x", Size: "10", Time: "Jan  1  0001"},
-		{Name: "go.mod", Mode: "-rw-r--r--", Size: "7", Time: "Jan  1  0001"},
+		{Name: "main.go", RawName: "main.go", Mode: "-rw-r--r--", LinkCount: "1", Owner: "?", Group: "?", Size: "4", Time: "?"},
+		{Name: "ted", RawName: "ted", Mode: "-rwxr-xr-x", LinkCount: "1", Owner: "?", Group: "?", Size: "10", Time: "?"},
+		{Name: "go.mod", RawName: "go.mod", Mode: "-rw-r--r--", LinkCount: "1", Owner: "?", Group: "?", Size: "7", Time: "?"},
 	}
 	tests := []struct {
 		name    string
@@ -22,14 +23,80 @@ func Test_processEntries(t *testing.T) {
 		want    []Entry
 		want1   Widths
 	}{
-		{name: "test1", entries: mockEntries, want: newEntries, want1: Widths{sizeCol: 2, timeCol: 12, modCol: 10}},
+		{name: "test1", entries: mockEntries, want: newEntries, want1: Widths{sizeCol: 2, timeCol: 1, modCol: 10, linkCol: 1, ownerCol: 1, groupCol: 1}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := prepareFileDetailsForDisplay(tt.entries)
+			got := NewLister(Flags{}).prepareFileDetailsForDisplay(tt.entries)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("processEntries() got = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestLister_numberColumns(t *testing.T) {
+	entries := []Entry{{Inode: "12", Blocks: "4"}, {Inode: "3456", Blocks: "1.2K"}, {Inode: "?", Blocks: "?"}}
+	w := getWidths(entries)
+	tests := []struct {
+		name  string
+		flags Flags
+		entry Entry
+		want  string
+	}{
+		{name: "test 1", flags: Flags{}, entry: entries[0], want: ""},
+		{name: "test 2", flags: Flags{Inode: true}, entry: entries[0], want: "  12 "},
+		{name: "test 3", flags: Flags{Size: true}, entry: entries[0], want: "   4 "},
+		{name: "test 4", flags: Flags{Inode: true, Size: true}, entry: entries[1], want: "3456 1.2K "},
+		{name: "test 5", flags: Flags{Inode: true, Size: true}, entry: entries[2], want: "   ?    ? "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewLister(tt.flags).numberColumns(tt.entry, w); got != tt.want {
+				t.Errorf("numberColumns() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// partialResolver only knows the user and group with ID 1000.
+type partialResolver struct{}
+
+func (partialResolver) LookupUser(uid uint32) (string, error) {
+	if uid != 1000 {
+		return "", fmt.Errorf("unknown user %d", uid)
+	}
+	return "alice", nil
+}
+
+func (partialResolver) LookupGroup(gid uint32) (string, error) {
+	if gid != 1000 {
+		return "", fmt.Errorf("unknown group %d", gid)
+	}
+	return "staff", nil
+}
+
+func TestLister_setOwners(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags Flags
+		stat  statInfo
+		want  Entry
+	}{
+		{name: "test 1", stat: statInfo{Uid: 1000, Gid: 1000}, want: Entry{Owner: "alice", Group: "staff"}},
+		{name: "test 2", stat: statInfo{Uid: 1000, Gid: 4242}, want: Entry{Owner: "alice", Group: "4242", NumericGroup: true}},
+		{name: "test 3", stat: statInfo{Uid: 65534, Gid: 1000}, want: Entry{Owner: "65534", Group: "staff", NumericOwner: true}},
+		{name: "test 4", flags: Flags{NumericIDs: true}, stat: statInfo{Uid: 1000, Gid: 1000}, want: Entry{Owner: "1000", Group: "1000", NumericOwner: true, NumericGroup: true}},
+		{name: "test 5", stat: statInfo{Uid: 7, Gid: 8, User: "bob", Group: "ops"}, want: Entry{Owner: "bob", Group: "ops"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Entry
+			l := &Lister{Flags: tt.flags, Resolver: partialResolver{}}
+			l.setOwners(&got, tt.stat)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("setOwners() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,343 @@
+package lsfunctions
+
+import (
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// quotingStyle returns the style the Lister quotes names in, as decided
+// once for the Lister by detectQuotingStyle.
+func (l *Lister) quotingStyle() QuotingStyle {
+	return l.settings().quoting
+}
+
+// detectQuotingStyle returns the style names are to be quoted in: the one
+// of the flags, else the one of the QUOTING_STYLE environment variable, else
+// shell-escape when writing to a terminal and literal otherwise. An invalid
+// QUOTING_STYLE is ignored.
+func (l *Lister) detectQuotingStyle() QuotingStyle {
+	if l.Flags.Quoting != "" {
+		return l.Flags.Quoting
+	}
+	if env := os.Getenv("QUOTING_STYLE"); env != "" {
+		for _, c := range quotingChoices {
+			if c.word == env {
+				return QuotingStyle(c.value)
+			}
+		}
+	}
+	if IsTerminal(l.Stdout) {
+		return QuoteShellEscape
+	}
+	return QuoteLiteral
+}
+
+// hideControlChars reports whether nonprintable characters left in names
+// after quoting are shown as "?", as decided once for the Lister by
+// detectHideControlChars.
+func (l *Lister) hideControlChars() bool {
+	return l.settings().hideControl
+}
+
+// detectHideControlChars reports whether nonprintable characters are to be
+// shown as "?". By default they are when writing to a terminal.
+func (l *Lister) detectHideControlChars() bool {
+	switch l.Flags.ControlChars {
+	case ControlHide:
+		return true
+	case ControlShow:
+		return false
+	}
+	return IsTerminal(l.Stdout)
+}
+
+// quoteFile returns the name of a file as the Lister shows it. The escape
+// style escapes spaces, and the characters an indicator style may append
+// are escaped, or call for quotes, so that they cannot be mistaken for one.
+func (l *Lister) quoteFile(name string) string {
+	settings := l.settings()
+	var extra string
+	if settings.quoting == QuoteEscape {
+		extra = " "
+	}
+	switch settings.indicator {
+	case IndicatorFileType:
+		extra += "=>@|"
+	case IndicatorClassify:
+		extra += "*=>@|"
+	}
+	return l.quote(name, extra)
+}
+
+// quote returns name as the Lister shows it. extra lists characters that
+// are escaped, or that call for quotes, on top of those of the style.
+func (l *Lister) quote(name, extra string) string {
+	settings := l.settings()
+	s := quoteName(name, settings.quoting, extra)
+	if settings.hideControl {
+		s = hideControl(s)
+	}
+	return s
+}
+
+// alignQuotes reports whether names that are not quoted get a leading space
+// to line up with the quoted ones, as GNU ls does in the long and column
+// formats with the shell and shell-escape styles.
+func (l *Lister) alignQuotes() bool {
+	style := l.quotingStyle()
+	if style != QuoteShell && style != QuoteShellEscape {
+		return false
+	}
+	return l.Flags.Long || (!l.onePerLine() && l.Flags.Width >= 0)
+}
+
+// isQuoted reports whether a name was put between quotes.
+func isQuoted(s string) bool {
+	return strings.HasPrefix(s, "'") || strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "\u2018")
+}
+
+// padUnquoted puts a space before the names that are not quoted when some
+// others are, so that the names line up past the opening quotes.
+func padUnquoted(entries []Entry) {
+	quoted := false
+	for _, e := range entries {
+		quoted = quoted || isQuoted(e.Name)
+	}
+	if !quoted {
+		return
+	}
+	for i := range entries {
+		if !isQuoted(entries[i].Name) {
+			entries[i].Name = " " + entries[i].Name
+		}
+	}
+}
+
+// hideControl replaces the nonprintable characters of s, and its invalid
+// bytes, by question marks.
+func hideControl(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if isPrintable(r, size) {
+			b.WriteString(s[i : i+size])
+		} else {
+			b.WriteByte('?')
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// isPrintable reports whether a character, decoded from size bytes, can be
+// written as it is.
+func isPrintable(r rune, size int) bool {
+	if r == utf8.RuneError && size <= 1 {
+		return false
+	}
+	return unicode.IsGraphic(r) || unicode.Is(unicode.Cf, r)
+}
+
+// shellSpecial lists the characters that have a meaning for the shell
+// wherever they are in a word.
+const shellSpecial = " !\"$&()*;<=>[^`|?"
+
+// shellCompatible lists the characters that mean the same between single
+// quotes for the shell and double quotes in C.
+const shellCompatible = " '%+,-./:]_0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// quoteName quotes name in style, the way the quotearg module of GNU does:
+//
+//   - literal writes the name as it is;
+//   - shell puts it between single quotes when the shell needs it to, and
+//     shell-always always does;
+//   - shell-escape and shell-escape-always do the same, but write
+//     nonprintable characters as $'\n' escapes;
+//   - c puts it between double quotes with C escapes, escape uses the C
+//     escapes alone, and locale puts it between the quotation marks of a
+//     UTF-8 locale with C escapes.
+//
+// Nonprintable characters the style has no escape for are kept.
+// The characters of extra are escaped too, or call for quotes in the shell
+// style.
+func quoteName(name string, style QuotingStyle, extra string) string {
+	var (
+		b                       strings.Builder
+		backslash, elide, shell bool
+		open, close             string
+		pendingEnd, singleQuote bool
+		compatible              = true
+	)
+	switch style {
+	case QuoteLiteral:
+		return name
+	case QuoteC:
+		open, close, backslash = `"`, `"`, true
+	case QuoteEscape:
+		backslash = true
+	case QuoteLocale:
+		open, close, backslash = "\u2018", "\u2019", true
+	case QuoteShellEscape:
+		backslash, elide, shell = true, true, true
+	case QuoteShell:
+		elide, shell = true, true
+	case QuoteShellEscapeAlways:
+		backslash, shell = true, true
+	case QuoteShellAlways:
+		shell = true
+	}
+	if shell {
+		open, close = "'", "'"
+	}
+	// force starts over without eliding the quotes of a shell style, once
+	// a character that needs them is found.
+	force := func() string {
+		if backslash {
+			return quoteName(name, QuoteShellEscapeAlways, extra)
+		}
+		return quoteName(name, QuoteShellAlways, extra)
+	}
+	if elide && name == "" {
+		return force()
+	}
+	if !elide {
+		b.WriteString(open)
+	}
+	for i := 0; i < len(name); {
+		r, size := utf8.DecodeRuneInString(name[i:])
+		c := name[i]
+		escaping := false
+		// startEscape begins a backslash escape, switching to a $'...'
+		// string in the shell styles.
+		startEscape := func() bool {
+			if elide {
+				return false
+			}
+			escaping = true
+			if shell && !pendingEnd {
+				b.WriteString("'$'")
+				pendingEnd = true
+			}
+			b.WriteByte('\\')
+			return true
+		}
+		// endEscape goes back to a plain quoted string after a $'...' one.
+		endEscape := func() {
+			if pendingEnd && !escaping {
+				b.WriteString("''")
+				pendingEnd = false
+			}
+		}
+		if backslash && !shell && close != "" && strings.HasPrefix(name[i:], close) {
+			// The closing quote of the c and locale styles.
+			if !startEscape() {
+				return force()
+			}
+			b.WriteString(close)
+			i += len(close)
+			compatible = false
+			continue
+		}
+		i += size
+
+		if size > 1 || c >= utf8.RuneSelf || (c < 0x20 || c == 0x7f) && !strings.ContainsRune("\a\b\f\n\r\t\v", rune(c)) {
+			// Multibyte and nonprintable characters, written as octal
+			// escapes, byte by byte, when they are not printable.
+			if !backslash || isPrintable(r, size) {
+				endEscape()
+				b.WriteString(name[i-size : i])
+				compatible = false
+				continue
+			}
+			for j := i - size; j < i; j++ {
+				if !startEscape() {
+					return force()
+				}
+				c := name[j]
+				b.WriteByte('0' + c>>6)
+				b.WriteByte('0' + c>>3&7)
+				b.WriteByte('0' + c&7)
+			}
+			compatible = false
+			continue
+		}
+
+		if strings.IndexByte(shellCompatible, c) < 0 && !(strings.IndexByte("#~{}", c) >= 0 && shellSpecialAt(name, i-1)) {
+			compatible = false
+		}
+		if esc := strings.IndexByte("\a\b\f\n\r\t\v", c); esc >= 0 {
+			if shell && elide && strings.IndexByte("\n\r\t", c) >= 0 {
+				return force()
+			}
+			if backslash {
+				if !startEscape() {
+					return force()
+				}
+				b.WriteByte("abfnrtv"[esc])
+				continue
+			}
+			endEscape()
+			b.WriteByte(c)
+			continue
+		}
+		switch {
+		case c == '\\':
+			if shell {
+				if elide {
+					return force()
+				}
+				endEscape()
+				b.WriteByte(c)
+				continue
+			}
+			if !startEscape() {
+				return force()
+			}
+			b.WriteByte(c)
+			continue
+		case c == '\'':
+			singleQuote = true
+			if shell {
+				if elide {
+					return force()
+				}
+				b.WriteString(`'\'`)
+				pendingEnd = false
+			}
+		case shell && elide && shellSpecialAt(name, i-1):
+			return force()
+		}
+		if strings.IndexByte(extra, c) >= 0 && (backslash && !shell || elide) {
+			if !startEscape() {
+				return force()
+			}
+			b.WriteByte(c)
+			continue
+		}
+		endEscape()
+		b.WriteByte(c)
+	}
+	if shell && !elide && singleQuote && compatible {
+		return quoteName(name, QuoteC, extra)
+	}
+	if !elide {
+		b.WriteString(close)
+	}
+	return b.String()
+}
+
+// shellSpecialAt reports whether the character at i of name has a meaning
+// for the shell: the characters of shellSpecial anywhere, "#" and "~" at the
+// start of a word, and "{" and "}" on their own.
+func shellSpecialAt(name string, i int) bool {
+	switch c := name[i]; c {
+	case '{', '}':
+		return len(name) == 1
+	case '#', '~':
+		return i == 0
+	default:
+		return strings.IndexByte(shellSpecial, c) >= 0
+	}
+}
@@ -0,0 +1,121 @@
+package lsfunctions
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_quoteName(t *testing.T) {
+	tests := []struct {
+		name  string
+		file  string
+		style QuotingStyle
+		extra string
+		want  string
+	}{
+		{name: "test 1", file: "a b", style: QuoteLiteral, want: "a b"},
+		{name: "test 2", file: "plain", style: QuoteShell, want: "plain"},
+		{name: "test 3", file: "plain", style: QuoteShellAlways, want: "'plain'"},
+		{name: "test 4", file: "a b", style: QuoteShell, want: "'a b'"},
+		{name: "test 5", file: "x$y", style: QuoteShellEscape, want: "'x$y'"},
+		{name: "test 6", file: "it's", style: QuoteShell, want: `"it's"`},
+		{name: "test 7", file: "it's $x", style: QuoteShellAlways, want: `'it'\''s $x'`},
+		{name: "test 8", file: "a\nb", style: QuoteShell, want: "'a\nb'"},
+		{name: "test 9", file: "a\nb", style: QuoteShellEscape, want: `'a'$'\n''b'`},
+		{name: "test 10", file: "a\n", style: QuoteShellEscapeAlways, want: `'a'$'\n'`},
+		{name: "test 11", file: "\x01\xff", style: QuoteShellEscape, want: `''$'\001\377'`},
+		{name: "test 12", file: "a\tb\"c\\", style: QuoteC, want: `"a\tb\"c\\"`},
+		{name: "test 13", file: "a b\n", style: QuoteEscape, extra: " ", want: `a\ b\n`},
+		{name: "test 14", file: "it's\n", style: QuoteLocale, want: "\u2018it's\\n\u2019"},
+		{name: "test 15", file: "#x~", style: QuoteShell, want: "'#x~'"},
+		{name: "test 16", file: "x#~", style: QuoteShell, want: "x#~"},
+		{name: "test 17", file: "", style: QuoteShellEscape, want: "''"},
+		{name: "test 18", file: "画像 1.png", style: QuoteShellEscape, want: "'画像 1.png'"},
+		{name: "test 19", file: "a:b", style: QuoteShell, extra: ":", want: "'a:b'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := quoteName(tt.file, tt.style, tt.extra); got != tt.want {
+				t.Errorf("quoteName(%q, %v) = %q, want %q", tt.file, tt.style, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_hideControl(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{name: "test 1", s: "a\nb", want: "a?b"},
+		{name: "test 2", s: "'\x01\x7f'", want: "'??'"},
+		{name: "test 3", s: "café\xff", want: "café?"},
+		{name: "test 4", s: "画像", want: "画像"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hideControl(tt.s); got != tt.want {
+				t.Errorf("hideControl() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLister_quoting(t *testing.T) {
+	entries := []FileDetails{
+		{Name: "a b", Path: "a b", Info: mockFileInfo{mode: 0o644}},
+		{Name: "c", Path: "c", Info: mockFileInfo{mode: 0o644}},
+		{Name: "d\ne", Path: "d\ne", Info: mockFileInfo{mode: 0o644}},
+	}
+	tests := []struct {
+		name  string
+		flags Flags
+		want  string
+	}{
+		{name: "literal", flags: Flags{OnePerLine: true}, want: "a b\nc\nd\ne\n"},
+		{name: "hidden control chars", flags: Flags{OnePerLine: true, ControlChars: ControlHide}, want: "a b\nc\nd?e\n"},
+		{name: "shell", flags: Flags{OnePerLine: true, Quoting: QuoteShell, ControlChars: ControlHide}, want: "'a b'\nc\n'd?e'\n"},
+		{name: "aligned columns", flags: Flags{Columns: true, Width: 80, Quoting: QuoteShellEscape}, want: "'a b'   c  'd'$'\\n''e'\n"},
+		{name: "escape", flags: Flags{Across: true, Width: 80, Quoting: QuoteEscape}, want: "a\\ b  c  d\\ne\n"},
+		{name: "c", flags: Flags{OnePerLine: true, Quoting: QuoteC}, want: "\"a b\"\n\"c\"\n\"d\\ne\"\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var w bytes.Buffer
+			tt.flags.Color = ColorNever
+			l := &Lister{Flags: tt.flags, Stdout: &w, Resolver: fakeResolver{}}
+			l.displayShortList(entries)
+			if got := w.String(); got != tt.want {
+				t.Errorf("displayShortList() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLister_quotingStyle(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags Flags
+		env   string
+		want  QuotingStyle
+	}{
+		{name: "test 1", env: "c", want: QuoteC},
+		{name: "test 2", env: "bogus", want: QuoteLiteral},
+		{name: "test 3", flags: Flags{Quoting: QuoteShell}, env: "c", want: QuoteShell},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("QUOTING_STYLE", tt.env)
+			l := &Lister{Flags: tt.flags, Stdout: &bytes.Buffer{}}
+			if got := l.quotingStyle(); got != tt.want {
+				t.Errorf("quotingStyle() = %v, want %v", got, tt.want)
+			}
+			// The environment is read once for the Lister.
+			t.Setenv("QUOTING_STYLE", "escape")
+			if got := l.quotingStyle(); got != tt.want {
+				t.Errorf("quotingStyle() = %v after the environment changed, want %v", got, tt.want)
+			}
+		})
+	}
+}
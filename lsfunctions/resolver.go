@@ -0,0 +1,179 @@
+package lsfunctions
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// IDResolver looks up the names of users and groups from their numeric IDs.
+// It must be safe for concurrent use, as the Lister looks up the owners of
+// several files at once.
+type IDResolver interface {
+	LookupUser(uid uint32) (string, error)
+	LookupGroup(gid uint32) (string, error)
+}
+
+// osResolver resolves IDs through the os/user package.
+type osResolver struct{}
+
+func (osResolver) LookupUser(uid uint32) (string, error) {
+	u, err := user.LookupId(strconv.FormatUint(uint64(uid), 10))
+	if err != nil {
+		return "", err
+	}
+	return u.Username, nil
+}
+
+func (osResolver) LookupGroup(gid uint32) (string, error) {
+	g, err := user.LookupGroupId(strconv.FormatUint(uint64(gid), 10))
+	if err != nil {
+		return "", err
+	}
+	return g.Name, nil
+}
+
+// CachingResolver remembers the answers of another IDResolver, failures
+// included, so that each ID is looked up once however many files it owns.
+// It is safe for concurrent use.
+type CachingResolver struct {
+	resolver IDResolver
+
+	mu     sync.Mutex
+	users  map[uint32]cachedName
+	groups map[uint32]cachedName
+}
+
+// cachedName is the answer of a lookup.
+type cachedName struct {
+	name string
+	err  error
+}
+
+// NewCachingResolver returns a resolver that asks r about each ID once.
+func NewCachingResolver(r IDResolver) *CachingResolver {
+	return &CachingResolver{
+		resolver: r,
+		users:    make(map[uint32]cachedName),
+		groups:   make(map[uint32]cachedName),
+	}
+}
+
+func (c *CachingResolver) LookupUser(uid uint32) (string, error) {
+	return c.lookup(c.users, uid, c.resolver.LookupUser)
+}
+
+func (c *CachingResolver) LookupGroup(gid uint32) (string, error) {
+	return c.lookup(c.groups, gid, c.resolver.LookupGroup)
+}
+
+// lookup returns the cached answer for id, asking lookup on a miss.
+// The lock is not held during the lookup, so a slow one does not hold up
+// the others; two goroutines missing the same ID may both ask.
+func (c *CachingResolver) lookup(cache map[uint32]cachedName, id uint32, lookup func(uint32) (string, error)) (string, error) {
+	c.mu.Lock()
+	cached, ok := cache[id]
+	c.mu.Unlock()
+	if ok {
+		return cached.name, cached.err
+	}
+	name, err := lookup(id)
+	c.mu.Lock()
+	cache[id] = cachedName{name: name, err: err}
+	c.mu.Unlock()
+	return name, err
+}
+
+// FileResolver resolves IDs from files in the format of /etc/passwd and
+// /etc/group. It reads them itself, without cgo or NSS, so it can resolve
+// the IDs of another system, such as the root file system of a container
+// image. It is safe for concurrent use.
+type FileResolver struct {
+	users, groups map[uint32]string
+}
+
+// NewFileResolver reads users from passwd and groups from group, either of
+// which may be nil. Comments, blank lines and malformed entries are skipped;
+// when an ID appears several times, its first name is used, as getpwuid does.
+func NewFileResolver(passwd, group io.Reader) (*FileResolver, error) {
+	r := &FileResolver{}
+	var err error
+	if r.users, err = parseIDFile(passwd, 2); err != nil {
+		return nil, fmt.Errorf("passwd: %w", err)
+	}
+	if r.groups, err = parseIDFile(group, 2); err != nil {
+		return nil, fmt.Errorf("group: %w", err)
+	}
+	return r, nil
+}
+
+// LoadFileResolver returns a FileResolver for the etc/passwd and etc/group
+// files under root, which is "/" for the running system. A missing file
+// resolves no IDs.
+func LoadFileResolver(root string) (*FileResolver, error) {
+	var readers [2]io.Reader
+	for i, name := range []string{"passwd", "group"} {
+		f, err := os.Open(filepath.Join(root, "etc", name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		readers[i] = f
+	}
+	return NewFileResolver(readers[0], readers[1])
+}
+
+func (r *FileResolver) LookupUser(uid uint32) (string, error) {
+	if name, ok := r.users[uid]; ok {
+		return name, nil
+	}
+	return "", user.UnknownUserIdError(int(uid))
+}
+
+func (r *FileResolver) LookupGroup(gid uint32) (string, error) {
+	if name, ok := r.groups[gid]; ok {
+		return name, nil
+	}
+	return "", user.UnknownGroupIdError(strconv.FormatUint(uint64(gid), 10))
+}
+
+// parseIDFile reads the names and IDs of a file of colon-separated fields,
+// the name being the first field and the ID the field at index idField.
+// Lines starting with "+" or "-", the NIS entries of the compat format,
+// are skipped.
+func parseIDFile(r io.Reader, idField int) (map[uint32]string, error) {
+	names := make(map[uint32]string)
+	if r == nil {
+		return names, nil
+	}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.ContainsAny(line[:1], "#+-") {
+			continue
+		}
+		fields := strings.Split(line, ":")
+		if len(fields) <= idField || fields[0] == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(fields[idField], 10, 32)
+		if err != nil {
+			continue
+		}
+		if _, seen := names[uint32(id)]; !seen {
+			names[uint32(id)] = fields[0]
+		}
+	}
+	return names, scanner.Err()
+}
@@ -0,0 +1,128 @@
+package lsfunctions
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testPasswd = `# users
+root:x:0:0:root:/root:/bin/bash
+alice:x:1000:1000:Alice:/home/alice:/bin/sh
+
++nisuser::::::
+broken:x:notanumber:0::/:
+toor:x:0:0:second root:/root:/bin/sh
+bob:x:1001:100:Bob:/home/bob:/bin/sh
+`
+
+const testGroup = `root:x:0:
+users:x:100:alice,bob
+staff:x:1000:
+`
+
+func TestFileResolver(t *testing.T) {
+	r, err := NewFileResolver(strings.NewReader(testPasswd), strings.NewReader(testGroup))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		lookup  func(uint32) (string, error)
+		id      uint32
+		want    string
+		wantErr bool
+	}{
+		{name: "test 1", lookup: r.LookupUser, id: 0, want: "root"},
+		{name: "test 2", lookup: r.LookupUser, id: 1001, want: "bob"},
+		{name: "test 3", lookup: r.LookupUser, id: 4242, wantErr: true},
+		{name: "test 4", lookup: r.LookupGroup, id: 100, want: "users"},
+		{name: "test 5", lookup: r.LookupGroup, id: 1000, want: "staff"},
+		{name: "test 6", lookup: r.LookupGroup, id: 1001, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.lookup(tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("lookup(%d) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("lookup(%d) = %q, want %q", tt.id, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFileResolver(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "etc"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "etc", "passwd"), []byte(testPasswd), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := LoadFileResolver(root)
+	if err != nil {
+		t.Fatalf("LoadFileResolver() error = %v", err)
+	}
+	if name, err := r.LookupUser(1000); err != nil || name != "alice" {
+		t.Errorf("LookupUser(1000) = %q, %v, want alice", name, err)
+	}
+	if _, err := r.LookupGroup(0); err == nil {
+		t.Errorf("LookupGroup(0) without a group file succeeded")
+	}
+}
+
+// countingResolver counts the lookups it answers.
+type countingResolver struct {
+	mu            sync.Mutex
+	users, groups int
+}
+
+func (r *countingResolver) LookupUser(uid uint32) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users++
+	if uid == 0 {
+		return "root", nil
+	}
+	return "", errors.New("unknown user")
+}
+
+func (r *countingResolver) LookupGroup(gid uint32) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.groups++
+	return "wheel", nil
+}
+
+func TestCachingResolver(t *testing.T) {
+	backend := &countingResolver{}
+	r := NewCachingResolver(backend)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				r.LookupUser(0)
+				r.LookupUser(5)
+				r.LookupGroup(0)
+			}
+		}()
+	}
+	wg.Wait()
+	if name, err := r.LookupUser(0); err != nil || name != "root" {
+		t.Errorf("LookupUser(0) = %q, %v, want root", name, err)
+	}
+	if _, err := r.LookupUser(5); err == nil {
+		t.Errorf("LookupUser(5) succeeded, want the cached failure")
+	}
+	// Goroutines missing the same ID at once may each ask, but no more.
+	if backend.users > 16 || backend.groups > 8 {
+		t.Errorf("backend asked %d users and %d groups, want each ID looked up about once", backend.users, backend.groups)
+	}
+}
@@ -6,70 +6,130 @@ import (
 	"strings"
 )
 
-func DisplayShortList(w io.Writer, e []FileDetails) {
-	// Process entries to create type []Entry
-	entries := prepareFileDetailsForDisplay(e)
-
-	var fileNameEntries []string
-	displayColumn := false
-	maxwith := 0
+// columnSeparator is the minimum gap between two columns of names.
+const columnSeparator = 2
 
-	for i := 0; i < len(entries); i++ {
-		if len(entries[i].Name) > maxwith {
-			maxwith = len(entries[i].Name)
-		}
-	}
+// DisplayShortList displays the entries as names only.
+// It is a shorthand for a Lister with the given flags that writes to w.
+func DisplayShortList(w io.Writer, e []FileDetails, flags Flags) {
+	l := NewLister(flags)
+	l.Stdout = w
+	l.displayShortList(e)
+}
 
-	for i := 0; i < len(entries); i++ {
-		entries[i].Name = entries[i].Name + strings.Repeat(" ", (maxwith-len(entries[i].Name)+5))
+// displayShortList displays the entries as names only.
+// By default the names are packed into as many columns as fit in the
+// terminal, filled down the columns. With the Across flag the columns are
+// filled across the rows instead, and with OnePerLine, or when the output
+// is not a terminal, every name gets its own line.
+func (l *Lister) displayShortList(e []FileDetails) {
+	// Process entries to create type []Entry
+	entries := l.prepareFileDetailsForDisplay(e)
+	if len(entries) == 0 {
+		return
 	}
+	flags := l.Flags
+	w := l.Stdout
 
-	// Prepare the list of file names and check if any entry is too long
-	for _, entry := range entries {
-		formattedName := getShortFormatString(entry)
-		if len(formattedName) > 100 {
-			displayColumn = true
+	numbers := getWidths(entries)
+	if l.onePerLine() {
+		for _, entry := range entries {
+			fmt.Fprintln(w, l.numberColumns(entry, numbers)+l.getShortFormatString(entry))
 		}
-		fileNameEntries = append(fileNameEntries, formattedName)
+		return
 	}
 
-	// Display in single-column format if any entry is too long
-	if displayColumn {
-		for _, name := range fileNameEntries {
-			fmt.Println(name)
+	extra := displayWidth(l.numberColumns(Entry{}, numbers))
+	cols, widths := calculateColumns(entries, l.lineWidth(), extra, flags.Across)
+	rows := (len(entries) + cols - 1) / cols
+
+	for r := 0; r < rows; r++ {
+		var line strings.Builder
+		for c := 0; c < cols; c++ {
+			i := gridIndex(r, c, rows, cols, flags.Across)
+			if i >= len(entries) {
+				break
+			}
+			next := gridIndex(r, c+1, rows, cols, flags.Across)
+			line.WriteString(l.numberColumns(entries[i], numbers) + l.getShortFormatString(entries[i]))
+			if c+1 < cols && next < len(entries) {
+				line.WriteString(strings.Repeat(" ", widths[c]-extra-entryWidth(entries[i])))
+			}
 		}
-		return
+		fmt.Fprintln(w, line.String())
 	}
+}
 
-	// For fewer than or equal to 8 items, display in a single line
-	if len(fileNameEntries) <= 8 {
-		fmt.Println(strings.Join(fileNameEntries, "  "))
-		return
+// calculateColumns finds the largest number of columns that fits the names
+// into lineWidth, the same way GNU ls packs its -C and -x output.
+// Each column is as wide as its longest name plus the separator, except the
+// last one, names being measured in terminal columns with entryWidth.
+// extra is the width of the inode and size columns written before every name.
+// It returns the number of columns and the width of each column.
+func calculateColumns(entries []Entry, lineWidth, extra int, across bool) (int, []int) {
+	maxCols := lineWidth / (1 + columnSeparator)
+	if maxCols < 1 {
+		maxCols = 1
+	}
+	if maxCols > len(entries) {
+		maxCols = len(entries)
 	}
 
-	// Arrange items into columns
-	const itemsPerRow = 4
-	var columns [][]string
-	for i := 0; i < len(fileNameEntries); i += itemsPerRow {
-		end := i + itemsPerRow
-		if end > len(fileNameEntries) {
-			end = len(fileNameEntries)
+	for cols := maxCols; cols > 1; cols-- {
+		rows := (len(entries) + cols - 1) / cols
+		widths := make([]int, cols)
+		lineLen := 0
+		fits := true
+		for i, entry := range entries {
+			c := i / rows
+			if across {
+				c = i % cols
+			}
+			width := extra + entryWidth(entry)
+			if c != cols-1 {
+				width += columnSeparator
+			}
+			if width > widths[c] {
+				lineLen += width - widths[c]
+				widths[c] = width
+				if lineLen >= lineWidth {
+					fits = false
+					break
+				}
+			}
+		}
+		if fits {
+			return cols, widths
 		}
-		columns = append(columns, fileNameEntries[i:end])
 	}
+	return 1, []int{0}
+}
 
-	// Print each row with aligned columns
-	for _, row := range columns {
-		for _, name := range row {
-			fmt.Fprint(w, name)
-		}
-		fmt.Fprintln(w)
+// onePerLine reports whether the short listing has one name per line: with
+// OnePerLine, or when the output is not a terminal and no other layout is set.
+func (l *Lister) onePerLine() bool {
+	flags := l.Flags
+	return flags.OnePerLine || (!flags.Columns && !flags.Across && !IsTerminal(l.Stdout))
+}
+
+// gridIndex returns the position in the entry list of the name shown at the
+// given row and column.
+func gridIndex(row, col, rows, cols int, across bool) int {
+	if across {
+		return row*cols + col
 	}
+	return col*rows + row
 }
 
-func getShortFormatString(entry Entry) string {
+func (l *Lister) getShortFormatString(entry Entry) string {
 	// Color output
-	entry = colorName(entry, false)
+	entry = l.colorName(entry, false)
+
+	return entry.Name + entry.Indicator
+}
 
-	return entry.Name
+// entryWidth returns the number of columns the name of an entry takes,
+// along with its indicator.
+func entryWidth(entry Entry) int {
+	return displayWidth(entry.Name) + len(entry.Indicator)
 }
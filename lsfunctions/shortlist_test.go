@@ -3,6 +3,7 @@ package lsfunctions
 import (
 	"bytes"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -20,7 +21,7 @@ func TestDisplayShortList(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			w := &bytes.Buffer{}
-			DisplayShortList(w, tt.args.e)
+			DisplayShortList(w, tt.args.e, Flags{})
 			os.Stdout.WriteString("here: " + w.String())
 			if gotW := w.String(); gotW != tt.wantW {
 				t.Errorf("DisplayShortList() = %v, want %v", gotW, tt.wantW)
@@ -28,3 +29,49 @@ func TestDisplayShortList(t *testing.T) {
 		})
 	}
 }
+
+func Test_calculateColumns(t *testing.T) {
+	names := []Entry{{Name: "a"}, {Name: "bbbb"}, {Name: "cc"}, {Name: "ddddd"}, {Name: "e"}}
+	tests := []struct {
+		name       string
+		lineWidth  int
+		across     bool
+		wantCols   int
+		wantWidths []int
+	}{
+		{name: "all on one line", lineWidth: 80, wantCols: 5, wantWidths: []int{3, 6, 4, 7, 1}},
+		{name: "down the columns", lineWidth: 15, wantCols: 3, wantWidths: []int{6, 7, 1}},
+		{name: "across the rows", lineWidth: 12, across: true, wantCols: 2, wantWidths: []int{4, 5}},
+		{name: "too narrow", lineWidth: 4, wantCols: 1, wantWidths: []int{0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotCols, gotWidths := calculateColumns(names, tt.lineWidth, 0, tt.across)
+			if gotCols != tt.wantCols {
+				t.Errorf("calculateColumns() cols = %v, want %v", gotCols, tt.wantCols)
+			}
+			if !reflect.DeepEqual(gotWidths, tt.wantWidths) {
+				t.Errorf("calculateColumns() widths = %v, want %v", gotWidths, tt.wantWidths)
+			}
+		})
+	}
+}
+
+func TestLister_displayShortList_color(t *testing.T) {
+	entries := []FileDetails{
+		{Name: "docs", Path: "docs", Info: mockFileInfo{mode: os.ModeDir | 0o755}},
+		{Name: "a.txt", Path: "a.txt", Info: mockFileInfo{mode: 0o644}},
+		{Name: "longer-name.go", Path: "longer-name.go", Info: mockFileInfo{mode: 0o644}},
+	}
+	var plain, colored bytes.Buffer
+	l := &Lister{Flags: Flags{Columns: true, Width: 20, Color: ColorNever}, Stdout: &plain, Resolver: fakeResolver{}}
+	l.displayShortList(entries)
+	l = &Lister{Flags: Flags{Columns: true, Width: 20, Color: ColorAlways}, Stdout: &colored, Resolver: fakeResolver{}}
+	l.displayShortList(entries)
+	if plain.String() == colored.String() {
+		t.Fatalf("displayShortList() with ColorAlways = %q, want escape sequences", colored.String())
+	}
+	if got := stripColor(colored.String()); got != plain.String() {
+		t.Errorf("displayShortList() with colour = %q, want the layout %q", got, plain.String())
+	}
+}
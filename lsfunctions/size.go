@@ -0,0 +1,159 @@
+package lsfunctions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sizeUnits are the prefixes used for human-readable sizes, starting at kilo.
+const sizeUnits = "KMGTPEZYRQ"
+
+// sizeFormat describes how a number of bytes is written.
+type sizeFormat struct {
+	// blockSize is the unit sizes are counted in, rounding up.
+	blockSize uint64
+	// suffix is appended to sizes counted in blockSize units.
+	suffix string
+	// human picks the unit automatically, in powers of base.
+	human bool
+	base  uint64
+}
+
+var (
+	bytesFormat = sizeFormat{blockSize: 1}
+	kiloFormat  = sizeFormat{blockSize: 1024}
+	humanFormat = sizeFormat{human: true, base: 1024}
+	siFormat    = sizeFormat{human: true, base: 1000}
+)
+
+// parseBlockSize parses the argument of --block-size.
+// It accepts "human-readable", "si", a unit such as "K", "MiB" or "KB"
+// (powers of 1024, 1024 and 1000), and a number optionally followed by a
+// unit such as "512" or "1MiB". Sizes given by a unit alone are printed with
+// that unit as a suffix, like GNU ls does.
+func parseBlockSize(spec string) (sizeFormat, error) {
+	switch spec {
+	case "human-readable":
+		return humanFormat, nil
+	case "si":
+		return siFormat, nil
+	}
+	invalid := fmt.Errorf("invalid --block-size argument '%s'", spec)
+
+	digits := 0
+	for digits < len(spec) && spec[digits] >= '0' && spec[digits] <= '9' {
+		digits++
+	}
+	number := uint64(1)
+	if digits > 0 {
+		n, err := strconv.ParseUint(spec[:digits], 10, 64)
+		if err != nil || n == 0 {
+			return sizeFormat{}, invalid
+		}
+		number = n
+	}
+	unit := spec[digits:]
+	if digits == 0 && unit == "" {
+		return sizeFormat{}, invalid
+	}
+
+	multiplier := uint64(1)
+	if unit != "" {
+		exp := strings.IndexByte(sizeUnits, unit[0]&^0x20) + 1
+		if exp == 0 {
+			return sizeFormat{}, invalid
+		}
+		base := uint64(1024)
+		switch unit[1:] {
+		case "", "iB":
+		case "B":
+			base = 1000
+		default:
+			return sizeFormat{}, invalid
+		}
+		for i := 0; i < exp; i++ {
+			if multiplier > ^uint64(0)/base {
+				return sizeFormat{}, invalid
+			}
+			multiplier *= base
+		}
+	}
+	if number > ^uint64(0)/multiplier {
+		return sizeFormat{}, invalid
+	}
+
+	f := sizeFormat{blockSize: number * multiplier}
+	if digits == 0 {
+		f.suffix = unit
+	}
+	return f, nil
+}
+
+// format writes a number of bytes in the format's unit.
+func (f sizeFormat) format(n uint64) string {
+	if f.human {
+		return humanSize(n, f.base)
+	}
+	blocks := n / f.blockSize
+	if n%f.blockSize != 0 {
+		blocks++
+	}
+	return strconv.FormatUint(blocks, 10) + f.suffix
+}
+
+// humanSize writes a number of bytes with a unit suffix, in powers of base,
+// rounding up as GNU ls -h does: one decimal below 10, none above.
+// Sizes smaller than base are written as they are.
+func humanSize(n, base uint64) string {
+	if n < base {
+		return strconv.FormatUint(n, 10)
+	}
+	exp := 0
+	divisor := uint64(1)
+	for n/divisor >= base && exp < len(sizeUnits) {
+		divisor *= base
+		exp++
+	}
+	q, r := n/divisor, n%divisor
+	if q < 10 {
+		tenths := q*10 + (r*10+divisor-1)/divisor
+		if tenths < 100 {
+			return fmt.Sprintf("%d.%d%s", tenths/10, tenths%10, unitSuffix(exp, base))
+		}
+		return "10" + unitSuffix(exp, base)
+	}
+	if r != 0 {
+		q++
+	}
+	if q >= base && exp < len(sizeUnits) {
+		return "1.0" + unitSuffix(exp+1, base)
+	}
+	return strconv.FormatUint(q, 10) + unitSuffix(exp, base)
+}
+
+// unitSuffix returns the suffix for base to the power of exp.
+// Like GNU ls, SI kilo is written as a lowercase "k".
+func unitSuffix(exp int, base uint64) string {
+	if exp == 1 && base == 1000 {
+		return "k"
+	}
+	return sizeUnits[exp-1 : exp]
+}
+
+// sizeFormats returns how the Lister writes file sizes and block counts
+// such as the "total" line. Without any option, file sizes are in bytes and
+// block counts in units of 1024 bytes.
+func (l *Lister) sizeFormats() (files, blocks sizeFormat) {
+	switch {
+	case l.Flags.HumanReadable:
+		return humanFormat, humanFormat
+	case l.Flags.SI:
+		return siFormat, siFormat
+	case l.Flags.BlockSize != "":
+		if f, err := parseBlockSize(l.Flags.BlockSize); err == nil {
+			return f, f
+		}
+	}
+	return bytesFormat, kiloFormat
+}
@@ -0,0 +1,89 @@
+package lsfunctions
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_humanSize(t *testing.T) {
+	tests := []struct {
+		name string
+		n    uint64
+		base uint64
+		want string
+	}{
+		{name: "bytes", n: 1023, base: 1024, want: "1023"},
+		{name: "exact kilo", n: 1024, base: 1024, want: "1.0K"},
+		{name: "rounds up", n: 1025, base: 1024, want: "1.1K"},
+		{name: "one and a half", n: 1536, base: 1024, want: "1.5K"},
+		{name: "ten", n: 10240, base: 1024, want: "10K"},
+		{name: "rounds up to ten", n: 10235, base: 1024, want: "10K"},
+		{name: "no decimal above ten", n: 10241, base: 1024, want: "11K"},
+		{name: "next unit", n: 1024*1024 - 1, base: 1024, want: "1.0M"},
+		{name: "gigabytes", n: 5 << 30, base: 1024, want: "5.0G"},
+		{name: "si kilo", n: 1100, base: 1000, want: "1.1k"},
+		{name: "si mega", n: 2500000, base: 1000, want: "2.5M"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := humanSize(tt.n, tt.base); got != tt.want {
+				t.Errorf("humanSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseBlockSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    sizeFormat
+		wantErr bool
+	}{
+		{name: "kilo", spec: "K", want: sizeFormat{blockSize: 1024, suffix: "K"}},
+		{name: "lowercase kilo", spec: "k", want: sizeFormat{blockSize: 1024, suffix: "k"}},
+		{name: "decimal kilo", spec: "KB", want: sizeFormat{blockSize: 1000, suffix: "KB"}},
+		{name: "binary mega", spec: "MiB", want: sizeFormat{blockSize: 1 << 20, suffix: "MiB"}},
+		{name: "number and unit", spec: "1MiB", want: sizeFormat{blockSize: 1 << 20}},
+		{name: "number", spec: "512", want: sizeFormat{blockSize: 512}},
+		{name: "human", spec: "human-readable", want: humanFormat},
+		{name: "si", spec: "si", want: siFormat},
+		{name: "empty", spec: "", wantErr: true},
+		{name: "zero", spec: "0", wantErr: true},
+		{name: "unknown unit", spec: "2X", wantErr: true},
+		{name: "too large", spec: "100000Y", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseBlockSize(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBlockSize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBlockSize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_sizeFormat_format(t *testing.T) {
+	tests := []struct {
+		name   string
+		format sizeFormat
+		n      uint64
+		want   string
+	}{
+		{name: "bytes", format: bytesFormat, n: 4097, want: "4097"},
+		{name: "kilo rounds up", format: kiloFormat, n: 4097, want: "5"},
+		{name: "suffix", format: sizeFormat{blockSize: 1024, suffix: "K"}, n: 100, want: "1K"},
+		{name: "empty file", format: sizeFormat{blockSize: 1024, suffix: "K"}, n: 0, want: "0K"},
+		{name: "human", format: humanFormat, n: 4096, want: "4.0K"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.format.format(tt.n); got != tt.want {
+				t.Errorf("format() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,9 +1,6 @@
 package lsfunctions
 
-import (
-	"os"
-	"strings"
-)
+import "strings"
 
 // SortPaths sorts a slice of file paths and separates directories from non-directories.
 //
@@ -20,6 +17,11 @@ import (
 //   - int: The index of the first directory in the sorted slice. If no directories
 //     are present, this will be equal to the length of the slice.
 func SortPaths(paths []string) ([]string, int) {
+	return sortPaths(osFS{}, paths)
+}
+
+// sortPaths sorts paths like SortPaths, looking them up in fsys.
+func sortPaths(fsys fileSystem, paths []string) ([]string, int) {
 	// Step 1: Bubble sort by the last component, alphabetically and case-insensitively
 	for k := 0; k < len(paths)-1; k++ {
 		for j := 0; j < len(paths)-1-k; j++ {
@@ -38,8 +40,8 @@ func SortPaths(paths []string) ([]string, int) {
 	// Step 2: Bubble sort to move directories to the back while preserving order
 	for k := 0; k < len(paths)-1; k++ {
 		for j := 0; j < len(paths)-1-k; j++ {
-			infoI, errI := os.Lstat(paths[j])
-			infoJ, errJ := os.Lstat(paths[j+1])
+			infoI, errI := fsys.Lstat(paths[j])
+			infoJ, errJ := fsys.Lstat(paths[j+1])
 
 			if errI != nil || errJ != nil {
 				continue // Ignore errors
@@ -55,7 +57,7 @@ func SortPaths(paths []string) ([]string, int) {
 	// Step 3: Find the index of the first non-directory
 	nonDirIdx := len(paths) // Default to the end if no non-directories are found
 	for i, path := range paths {
-		info, err := os.Lstat(path)
+		info, err := fsys.Lstat(path)
 		if err != nil {
 			continue
 		}
@@ -78,7 +80,34 @@ func SortPaths(paths []string) ([]string, int) {
 // Returns:
 //   - []FileInfo: A sorted slice of FileInfo structures.
 func sortEntries(entries []FileDetails, flags Flags) []FileDetails {
-	quickSort(entries, 0, len(entries)-1, flags)
+	return sortEntriesBy(entries, flags, func(a, b FileDetails) bool {
+		return compareEntries(a, b, flags)
+	})
+}
+
+// sortEntries sorts entries with the Lister's comparator, or by the
+// sort key of its flags when it has none.
+// Like GNU ls, choosing access, change or birth time without -l also sorts
+// by that time unless another order was asked for.
+func (l *Lister) sortEntries(entries []FileDetails) []FileDetails {
+	flags := l.Flags
+	if flags.Sort == "" && flags.TimeField != TimeModification && !flags.Long {
+		flags.Sort = SortTime
+	}
+	if l.Less == nil {
+		return sortEntries(entries, flags)
+	}
+	return sortEntriesBy(entries, flags, l.Less)
+}
+
+// sortEntriesBy sorts entries with less and then reverses them for the
+// Reverse flag. With SortNone the entries are left in directory order,
+// after "." and ".." with the All flag.
+func sortEntriesBy(entries []FileDetails, flags Flags, less func(a, b FileDetails) bool) []FileDetails {
+	if flags.Sort == SortNone {
+		return entries
+	}
+	quickSort(entries, 0, len(entries)-1, less)
 
 	if flags.Reverse {
 		for i := len(entries)/2 - 1; i >= 0; i-- {
@@ -90,21 +119,21 @@ func sortEntries(entries []FileDetails, flags Flags) []FileDetails {
 }
 
 // quickSort implements the quicksort algorithm
-func quickSort(entries []FileDetails, low, high int, flags Flags) {
+func quickSort(entries []FileDetails, low, high int, less func(a, b FileDetails) bool) {
 	if low < high {
-		pi := partition(entries, low, high, flags)
-		quickSort(entries, low, pi-1, flags)
-		quickSort(entries, pi+1, high, flags)
+		pi := partition(entries, low, high, less)
+		quickSort(entries, low, pi-1, less)
+		quickSort(entries, pi+1, high, less)
 	}
 }
 
 // partition is a helper function for quickSort
-func partition(entries []FileDetails, low, high int, flags Flags) int {
+func partition(entries []FileDetails, low, high int, less func(a, b FileDetails) bool) int {
 	pivot := entries[high]
 	i := low - 1
 
 	for j := low; j < high; j++ {
-		if compareEntries(entries[j], pivot, flags) {
+		if less(entries[j], pivot) {
 			i++
 			entries[i], entries[j] = entries[j], entries[i]
 		}
@@ -114,19 +143,177 @@ func partition(entries []FileDetails, low, high int, flags Flags) int {
 	return i + 1
 }
 
-// compareEntries compares two FileInfo entries based on the sorting criteria
+// compareEntries compares two FileInfo entries based on the sorting criteria.
+// It reports whether a is listed before b. Entries that are equal under the
+// sort key are ordered by name.
 func compareEntries(a, b FileDetails, flags Flags) bool {
-	if flags.Time {
-		if a.Info.ModTime().Nanosecond() != b.Info.ModTime().Nanosecond() {
-			return a.Info.ModTime().After(b.Info.ModTime())
+	switch flags.Sort {
+	case SortTime:
+		ta, _ := entryTime(a, flags.TimeField)
+		tb, _ := entryTime(b, flags.TimeField)
+		if !ta.Equal(tb) {
+			return ta.After(tb)
+		}
+	case SortSize:
+		if a.Info.Size() != b.Info.Size() {
+			return a.Info.Size() > b.Info.Size()
+		}
+	case SortExtension:
+		if ea, eb := getExtension(a.Name), getExtension(b.Name); ea != eb {
+			return ea < eb
 		}
+	case SortVersion:
+		return compareVersions(a.Name, b.Name) < 0
 	}
+	return compareNames(a.Name, b.Name)
+}
 
-	s1 := strings.ToLower(a.Name)
-	s2 := strings.ToLower(b.Name)
+// compareNames reports whether name a is listed before name b.
+// Names are compared case-insensitively, ignoring punctuation.
+func compareNames(a, b string) bool {
+	s1 := strings.ToLower(a)
+	s2 := strings.ToLower(b)
 
 	if cleanName(s1) == cleanName(s2) {
-		return a.Name < b.Name
+		return a < b
 	}
 	return cleanName(s1) < cleanName(s2)
 }
+
+// getExtension returns the part of a name from its last dot, or "" when it has none.
+func getExtension(name string) string {
+	if i := strings.LastIndexByte(name, '.'); i >= 0 {
+		return name[i:]
+	}
+	return ""
+}
+
+// compareVersions compares two names the way GNU ls -v does, treating runs
+// of digits as numbers so that "file2" comes before "file10". Hidden files
+// come first, and a trailing file suffix such as ".tar.gz" only breaks ties.
+// It returns a negative number when a sorts first, positive when b does.
+func compareVersions(a, b string) int {
+	if a == b {
+		return 0
+	}
+	switch {
+	case a == "":
+		return -1
+	case b == "":
+		return 1
+	case a == ".":
+		return -1
+	case b == ".":
+		return 1
+	case a == "..":
+		return -1
+	case b == "..":
+		return 1
+	}
+	aHidden, bHidden := a[0] == '.', b[0] == '.'
+	if aHidden != bHidden {
+		if aHidden {
+			return -1
+		}
+		return 1
+	}
+	pa, pb := a, b
+	if aHidden {
+		pa, pb = a[1:], b[1:]
+	}
+	pa, pb = pa[:len(pa)-len(fileSuffix(pa))], pb[:len(pb)-len(fileSuffix(pb))]
+
+	var result int
+	if pa == pb {
+		result = compareVersionParts(a, b)
+	} else {
+		result = compareVersionParts(pa, pb)
+	}
+	if result != 0 {
+		return result
+	}
+	return strings.Compare(a, b)
+}
+
+// fileSuffix returns the trailing file suffix of a name, made of parts
+// like ".gz" that start with a letter or "~" after the dot.
+func fileSuffix(name string) string {
+	start := len(name)
+	for i := len(name) - 1; i >= 0; i-- {
+		c := name[i]
+		if c == '.' && i+1 < len(name) && (isLetter(name[i+1]) || name[i+1] == '~') {
+			start = i
+			continue
+		}
+		if !isLetter(c) && !isDigit(c) && c != '~' {
+			break
+		}
+	}
+	if start == 0 {
+		return ""
+	}
+	return name[start:]
+}
+
+// compareVersionParts compares alternating runs of non-digits and digits.
+// Non-digits compare by versionOrder and digits compare as numbers.
+func compareVersionParts(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			ca, cb := 0, 0
+			if i < len(a) {
+				ca = versionOrder(a[i])
+			}
+			if j < len(b) {
+				cb = versionOrder(b[j])
+			}
+			if ca != cb {
+				return ca - cb
+			}
+			i++
+			j++
+		}
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+		firstDiff := 0
+		for i < len(a) && j < len(b) && isDigit(a[i]) && isDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+	return 0
+}
+
+// versionOrder ranks a non-digit byte for version comparison:
+// "~" sorts before everything, letters before other characters.
+func versionOrder(c byte) int {
+	switch {
+	case isDigit(c):
+		return 0
+	case isLetter(c):
+		return int(c)
+	case c == '~':
+		return -1
+	}
+	return int(c) + 256
+}
+
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
@@ -0,0 +1,133 @@
+package lsfunctions
+
+import (
+	"archive/tar"
+	"io/fs"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// statInfo holds the details of a file that fs.FileInfo does not expose.
+type statInfo struct {
+	// Dev and Ino are the device and inode numbers, zero when they are
+	// not known.
+	Dev, Ino uint64
+	Uid, Gid uint32
+	// User and Group are the names recorded with the file, such as the
+	// owner names kept in a tar header. They are empty for files on disk.
+	User, Group string
+	Nlink       uint64
+	Rdev        uint64
+	// Blocks is the number of 512-byte blocks allocated to the file.
+	Blocks int64
+	// Atime and Ctime are the last access and status change times.
+	Atime, Ctime time.Time
+}
+
+// fileID identifies a file by its device and inode numbers.
+type fileID struct {
+	dev, ino uint64
+}
+
+// fileStat returns the system-specific details of a file.
+// It understands the stat results of the operating system and tar headers,
+// and reports false for any other source.
+func fileStat(info fs.FileInfo) (statInfo, bool) {
+	switch sys := info.Sys().(type) {
+	case *syscall.Stat_t:
+		return statInfo{
+			Dev:    uint64(sys.Dev),
+			Ino:    sys.Ino,
+			Uid:    sys.Uid,
+			Gid:    sys.Gid,
+			Nlink:  uint64(sys.Nlink),
+			Rdev:   uint64(sys.Rdev),
+			Blocks: sys.Blocks,
+			Atime:  time.Unix(sys.Atim.Unix()),
+			Ctime:  time.Unix(sys.Ctim.Unix()),
+		}, true
+	case *tar.Header:
+		return statInfo{
+			Uid:    uint32(sys.Uid),
+			Gid:    uint32(sys.Gid),
+			User:   sys.Uname,
+			Group:  sys.Gname,
+			Nlink:  1,
+			Rdev:   makedev(uint64(sys.Devmajor), uint64(sys.Devminor)),
+			Blocks: (sys.Size + 511) / 512,
+			Atime:  sys.AccessTime,
+			Ctime:  sys.ChangeTime,
+		}, true
+	}
+	return statInfo{}, false
+}
+
+// ownerNames returns the user and group names of a file, preferring the
+// names recorded with it over those looked up by the Lister's resolver.
+// A name that cannot be found is returned empty; setOwners shows the ID instead.
+func (l *Lister) ownerNames(st statInfo) (owner, group string) {
+	owner, group = st.User, st.Group
+	if owner == "" {
+		if name, err := l.resolver().LookupUser(st.Uid); err == nil {
+			owner = name
+		}
+	}
+	if group == "" {
+		if name, err := l.resolver().LookupGroup(st.Gid); err == nil {
+			group = name
+		}
+	}
+	return owner, group
+}
+
+// setOwners fills in the owner and group columns of the long format: their
+// names or, with NumericIDs or when a name cannot be found, their IDs.
+func (l *Lister) setOwners(f *Entry, st statInfo) {
+	var owner, group string
+	if !l.Flags.NumericIDs {
+		owner, group = l.ownerNames(st)
+	}
+	f.Owner, f.NumericOwner = owner, owner == ""
+	if f.NumericOwner {
+		f.Owner = strconv.FormatUint(uint64(st.Uid), 10)
+	}
+	f.Group, f.NumericGroup = group, group == ""
+	if f.NumericGroup {
+		f.Group = strconv.FormatUint(uint64(st.Gid), 10)
+	}
+}
+
+// entryTime returns the timestamp of an entry selected by field.
+// Access and change times fall back to the modification time when the
+// file system does not provide them. It reports false for an unknown
+// birth time.
+func entryTime(entry FileDetails, field TimeField) (time.Time, bool) {
+	switch field {
+	case TimeBirth:
+		return entry.BirthTime, !entry.BirthTime.IsZero()
+	case TimeAccess, TimeChange:
+		if stat, ok := fileStat(entry.Info); ok {
+			t := stat.Atime
+			if field == TimeChange {
+				t = stat.Ctime
+			}
+			if !t.IsZero() {
+				return t, true
+			}
+		}
+	}
+	return entry.Info.ModTime(), true
+}
+
+// fillBirthTimes looks up the birth time of the entries when the flags
+// select it, as it is not part of the information readDir collects.
+func (l *Lister) fillBirthTimes(entries []FileDetails) {
+	if l.Flags.TimeField != TimeBirth {
+		return
+	}
+	fsys := l.fsys()
+	l.pool().forEach(len(entries), func(i int) {
+		entries[i].BirthTime, _ = fsys.BirthTime(entries[i].Path)
+	})
+}
@@ -0,0 +1,85 @@
+package lsfunctions
+
+import (
+	"runtime"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// sysStatx is the number of the statx system call on each architecture.
+// The syscall package predates statx and does not define it.
+var sysStatx = map[string]uintptr{
+	"386":      383,
+	"amd64":    332,
+	"arm":      397,
+	"arm64":    291,
+	"loong64":  291,
+	"mips":     4366,
+	"mipsle":   4366,
+	"mips64":   5326,
+	"mips64le": 5326,
+	"ppc64":    383,
+	"ppc64le":  383,
+	"riscv64":  291,
+	"s390x":    379,
+}
+
+const (
+	atFdcwd           = -0x64
+	atSymlinkNofollow = 0x100
+	statxBtime        = 0x800
+)
+
+// statxTimestamp mirrors struct statx_timestamp.
+type statxTimestamp struct {
+	Sec  int64
+	Nsec uint32
+	_    int32
+}
+
+// statxResult mirrors struct statx from linux/stat.h.
+type statxResult struct {
+	Mask           uint32
+	Blksize        uint32
+	Attributes     uint64
+	Nlink          uint32
+	Uid            uint32
+	Gid            uint32
+	Mode           uint16
+	_              uint16
+	Ino            uint64
+	Size           uint64
+	Blocks         uint64
+	AttributesMask uint64
+	Atime          statxTimestamp
+	Btime          statxTimestamp
+	Ctime          statxTimestamp
+	Mtime          statxTimestamp
+	RdevMajor      uint32
+	RdevMinor      uint32
+	DevMajor       uint32
+	DevMinor       uint32
+	_              [14]uint64
+}
+
+// birthTime returns the creation time of the file at path using statx.
+// It reports false when the kernel or the file system does not record it.
+func birthTime(path string) (time.Time, bool) {
+	trap, ok := sysStatx[runtime.GOARCH]
+	if !ok {
+		return time.Time{}, false
+	}
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	var stx statxResult
+	dirfd := atFdcwd
+	_, _, errno := syscall.Syscall6(trap, uintptr(dirfd), uintptr(unsafe.Pointer(p)),
+		atSymlinkNofollow, statxBtime, uintptr(unsafe.Pointer(&stx)), 0)
+	if errno != 0 || stx.Mask&statxBtime == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(stx.Btime.Sec, int64(stx.Btime.Nsec)), true
+}
@@ -0,0 +1,58 @@
+package lsfunctions
+
+import (
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+// defaultLineWidth is used when the output width cannot be determined.
+const defaultLineWidth = 80
+
+// winsize mirrors the kernel's struct winsize used by the TIOCGWINSZ ioctl.
+type winsize struct {
+	Row, Col, Xpixel, Ypixel uint16
+}
+
+// getTerminalWidth returns the number of columns available on the writer.
+// It asks the terminal through the TIOCGWINSZ ioctl when w is a TTY, then
+// falls back to the COLUMNS environment variable and finally to 80 columns.
+func getTerminalWidth(w io.Writer) int {
+	if f, ok := w.(*os.File); ok {
+		var ws winsize
+		_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+		if errno == 0 && ws.Col > 0 {
+			return int(ws.Col)
+		}
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	return defaultLineWidth
+}
+
+// lineWidth returns the width the Lister fills with columns: the one given
+// with -w, or else the width of its output.
+func (l *Lister) lineWidth() int {
+	switch {
+	case l.Flags.Width > 0:
+		return l.Flags.Width
+	case l.Flags.Width < 0:
+		return math.MaxInt32
+	}
+	return getTerminalWidth(l.Stdout)
+}
+
+// IsTerminal reports whether the writer is connected to a terminal.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	var termios syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TCGETS), uintptr(unsafe.Pointer(&termios)))
+	return errno == 0
+}
@@ -0,0 +1,159 @@
+package lsfunctions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sixMonths is how old a timestamp can be before the long format writes its
+// year instead of its time of day. GNU ls uses half of an average Gregorian year.
+const sixMonths = 31556952 / 2 * time.Second
+
+// timeStyle describes how timestamps are written in the long format, as a
+// pair of strftime formats for recent and old timestamps.
+type timeStyle struct {
+	recent, old string
+}
+
+var (
+	localeStyle  = timeStyle{recent: "%b %e %H:%M", old: "%b %e  %Y"}
+	fullISOStyle = timeStyle{recent: "%Y-%m-%d %H:%M:%S.%N %z", old: "%Y-%m-%d %H:%M:%S.%N %z"}
+	longISOStyle = timeStyle{recent: "%Y-%m-%d %H:%M", old: "%Y-%m-%d %H:%M"}
+	isoStyle     = timeStyle{recent: "%m-%d %H:%M", old: "%Y-%m-%d "}
+)
+
+// parseTimeStyle parses the argument of --time-style.
+// It accepts "full-iso", "long-iso", "iso", "locale" and "+FORMAT". A FORMAT
+// holding a newline gives the format for old timestamps before it and the one
+// for recent timestamps after it. A "posix-" prefix is accepted and ignored,
+// as the Lister always writes timestamps in the POSIX locale.
+func parseTimeStyle(spec string) (timeStyle, error) {
+	if format, ok := strings.CutPrefix(spec, "+"); ok {
+		if old, recent, ok := strings.Cut(format, "\n"); ok {
+			return timeStyle{recent: recent, old: old}, nil
+		}
+		return timeStyle{recent: format, old: format}, nil
+	}
+	switch strings.TrimPrefix(spec, "posix-") {
+	case "full-iso":
+		return fullISOStyle, nil
+	case "long-iso":
+		return longISOStyle, nil
+	case "iso":
+		return isoStyle, nil
+	case "locale":
+		return localeStyle, nil
+	}
+	return timeStyle{}, fmt.Errorf("invalid argument '%s' for 'time style'\nValid arguments are:\n"+
+		"  - [posix-]full-iso\n  - [posix-]long-iso\n  - [posix-]iso\n  - [posix-]locale\n"+
+		"  - +FORMAT (e.g., +%%H:%%M) for a 'date'-style format"+tryHelp, spec)
+}
+
+// format writes t in the style. Timestamps from the last six months are
+// recent; older ones, and those in the future, are old.
+func (s timeStyle) format(t, now time.Time) string {
+	if t.After(now.Add(-sixMonths)) && !t.After(now) {
+		return strftime(s.recent, t)
+	}
+	return strftime(s.old, t)
+}
+
+// timeStyle returns the style the Lister writes timestamps in.
+func (l *Lister) timeStyle() timeStyle {
+	if l.Flags.TimeStyle != "" {
+		if s, err := parseTimeStyle(l.Flags.TimeStyle); err == nil {
+			return s
+		}
+	}
+	return localeStyle
+}
+
+// strftime writes t following format, which uses the conversions of the
+// C strftime function in the POSIX locale, along with %N for nanoseconds.
+// Unknown conversions are written as they are.
+func strftime(format string, t time.Time) string {
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 == len(format) {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+		switch c := format[i]; c {
+		case 'a':
+			b.WriteString(t.Format("Mon"))
+		case 'A':
+			b.WriteString(t.Format("Monday"))
+		case 'b', 'h':
+			b.WriteString(t.Format("Jan"))
+		case 'B':
+			b.WriteString(t.Format("January"))
+		case 'c':
+			b.WriteString(t.Format("Mon Jan _2 15:04:05 2006"))
+		case 'C':
+			fmt.Fprintf(&b, "%02d", t.Year()/100)
+		case 'd':
+			fmt.Fprintf(&b, "%02d", t.Day())
+		case 'D':
+			b.WriteString(t.Format("01/02/06"))
+		case 'e':
+			fmt.Fprintf(&b, "%2d", t.Day())
+		case 'F':
+			b.WriteString(t.Format("2006-01-02"))
+		case 'H':
+			fmt.Fprintf(&b, "%02d", t.Hour())
+		case 'I':
+			fmt.Fprintf(&b, "%02d", (t.Hour()+11)%12+1)
+		case 'j':
+			fmt.Fprintf(&b, "%03d", t.YearDay())
+		case 'k':
+			fmt.Fprintf(&b, "%2d", t.Hour())
+		case 'l':
+			fmt.Fprintf(&b, "%2d", (t.Hour()+11)%12+1)
+		case 'm':
+			fmt.Fprintf(&b, "%02d", t.Month())
+		case 'M':
+			fmt.Fprintf(&b, "%02d", t.Minute())
+		case 'n':
+			b.WriteByte('\n')
+		case 'N':
+			fmt.Fprintf(&b, "%09d", t.Nanosecond())
+		case 'p':
+			b.WriteString(t.Format("PM"))
+		case 'P':
+			b.WriteString(strings.ToLower(t.Format("PM")))
+		case 'r':
+			b.WriteString(t.Format("03:04:05 PM"))
+		case 'R':
+			b.WriteString(t.Format("15:04"))
+		case 's':
+			b.WriteString(strconv.FormatInt(t.Unix(), 10))
+		case 'S':
+			fmt.Fprintf(&b, "%02d", t.Second())
+		case 't':
+			b.WriteByte('\t')
+		case 'T':
+			b.WriteString(t.Format("15:04:05"))
+		case 'u':
+			fmt.Fprintf(&b, "%d", (int(t.Weekday())+6)%7+1)
+		case 'w':
+			fmt.Fprintf(&b, "%d", t.Weekday())
+		case 'y':
+			fmt.Fprintf(&b, "%02d", t.Year()%100)
+		case 'Y':
+			b.WriteString(t.Format("2006"))
+		case 'z':
+			b.WriteString(t.Format("-0700"))
+		case 'Z':
+			b.WriteString(t.Format("MST"))
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
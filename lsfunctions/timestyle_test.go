@@ -0,0 +1,79 @@
+package lsfunctions
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_timeStyle_format(t *testing.T) {
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		style timeStyle
+		t     time.Time
+		want  string
+	}{
+		{name: "last december is recent", style: localeStyle, t: time.Date(2024, 12, 20, 9, 5, 0, 0, time.UTC), want: "Dec 20 09:05"},
+		{name: "seven months ago is old", style: localeStyle, t: time.Date(2024, 6, 1, 9, 5, 0, 0, time.UTC), want: "Jun  1  2024"},
+		{name: "future is old", style: localeStyle, t: time.Date(2025, 1, 11, 9, 5, 0, 0, time.UTC), want: "Jan 11  2025"},
+		{name: "full-iso", style: fullISOStyle, t: time.Date(2024, 12, 20, 9, 5, 7, 42, time.UTC), want: "2024-12-20 09:05:07.000000042 +0000"},
+		{name: "long-iso", style: longISOStyle, t: time.Date(2020, 3, 4, 9, 5, 0, 0, time.UTC), want: "2020-03-04 09:05"},
+		{name: "iso recent", style: isoStyle, t: time.Date(2025, 1, 2, 9, 5, 0, 0, time.UTC), want: "01-02 09:05"},
+		{name: "iso old", style: isoStyle, t: time.Date(2020, 3, 4, 9, 5, 0, 0, time.UTC), want: "2020-03-04 "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.style.format(tt.t, now); got != tt.want {
+				t.Errorf("format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseTimeStyle(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    timeStyle
+		wantErr bool
+	}{
+		{name: "test 1", spec: "long-iso", want: longISOStyle},
+		{name: "test 2", spec: "posix-iso", want: isoStyle},
+		{name: "test 3", spec: "+%H:%M", want: timeStyle{recent: "%H:%M", old: "%H:%M"}},
+		{name: "test 4", spec: "+%Y\n%H:%M", want: timeStyle{recent: "%H:%M", old: "%Y"}},
+		{name: "test 5", spec: "short", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTimeStyle(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimeStyle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseTimeStyle() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_strftime(t *testing.T) {
+	tm := time.Date(2024, 2, 5, 15, 4, 9, 0, time.UTC)
+	tests := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{name: "test 1", format: "%a %A %b %B", want: "Mon Monday Feb February"},
+		{name: "test 2", format: "%d/%e/%j/%m", want: "05/ 5/036/02"},
+		{name: "test 3", format: "%I:%M %p %k", want: "03:04 PM 15"},
+		{name: "test 4", format: "%F %T %s", want: "2024-02-05 15:04:09 1707145449"},
+		{name: "test 5", format: "100%% %q", want: "100% %q"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strftime(tt.format, tm); got != tt.want {
+				t.Errorf("strftime() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
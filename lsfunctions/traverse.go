@@ -0,0 +1,167 @@
+package lsfunctions
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// walker reads the directories of a listing ahead of their turn to be
+// printed, on a fixed number of goroutines. The listing asks for the
+// subdirectories of a directory as soon as it prints it and then waits for
+// them one after the other, in the order of GNU ls -R. They are read before
+// the directories asked for earlier, which are printed after them, so the
+// queue is always read in the order the directories are printed: depth
+// first. Only a few directories are read ahead of the one being waited for;
+// the rest wait in the queue as bare paths.
+type walker struct {
+	l   *Lister
+	ctx context.Context
+	// open holds a token for each directory being read, bounding the
+	// number of directories open at once.
+	open chan struct{}
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []*dirRead
+	closed bool
+	// ahead counts the directories taken by the goroutines of the walker
+	// and not waited for yet. No more than maxAhead are taken at once.
+	ahead    int
+	maxAhead int
+	wg       sync.WaitGroup
+}
+
+// dirRead is a directory read by the walker.
+type dirRead struct {
+	path string
+	done chan struct{}
+	// taken is set once the directory is taken from the queue, and ahead
+	// when a goroutine of the walker took it rather than the listing.
+	taken, ahead bool
+	// entries and err are the results of readDir. id identifies the
+	// directory when hasID is set, for the loop detection of -R.
+	entries []FileDetails
+	err     error
+	id      fileID
+	hasID   bool
+}
+
+// newWalker starts the goroutines of a walker: one per job, each taking a
+// token of the Lister's pool while it reads, and reading up to maxOpenFiles
+// directories at once. They stop reading when ctx is done.
+func (l *Lister) newWalker(ctx context.Context) *walker {
+	jobs := l.jobs()
+	w := &walker{l: l, ctx: ctx, open: make(chan struct{}, l.maxOpenFiles()), maxAhead: 2 * jobs}
+	w.cond = sync.NewCond(&w.mu)
+	w.wg.Add(jobs)
+	for i := 0; i < jobs; i++ {
+		go w.work()
+	}
+	return w
+}
+
+// maxOpenFiles returns the number of directories a listing reads at once:
+// the MaxOpenFiles flag, or one per job by default. As each read takes a
+// token of the pool, no more than jobs directories are read at once
+// whatever the flag.
+func (l *Lister) maxOpenFiles() int {
+	if l.Flags.MaxOpenFiles > 0 {
+		return l.Flags.MaxOpenFiles
+	}
+	return l.jobs()
+}
+
+// read asks for the directories at paths to be read, before the ones asked
+// for earlier, and in the order given.
+func (w *walker) read(paths []string) []*dirRead {
+	reads := make([]*dirRead, len(paths))
+	for i, path := range paths {
+		reads[i] = &dirRead{path: path, done: make(chan struct{})}
+	}
+	w.mu.Lock()
+	w.queue = slices.Concat(reads, w.queue)
+	w.mu.Unlock()
+	w.cond.Broadcast()
+	return reads
+}
+
+// wait waits for r to be read, reading it on the calling goroutine when no
+// goroutine of the walker has taken it yet. It reports false when the
+// listing was cancelled first.
+func (w *walker) wait(r *dirRead) bool {
+	w.mu.Lock()
+	if !r.taken {
+		r.taken = true
+		w.queue = slices.DeleteFunc(w.queue, func(q *dirRead) bool { return q == r })
+		w.mu.Unlock()
+		w.readDir(r)
+		return w.ctx.Err() == nil
+	}
+	w.mu.Unlock()
+	select {
+	case <-r.done:
+	case <-w.ctx.Done():
+		return false
+	}
+	if r.ahead {
+		w.mu.Lock()
+		w.ahead--
+		w.mu.Unlock()
+		w.cond.Broadcast()
+	}
+	return w.ctx.Err() == nil
+}
+
+// work reads the directories of the queue until the walker is stopped,
+// keeping at most maxAhead of them read ahead of their turn.
+func (w *walker) work() {
+	defer w.wg.Done()
+	for {
+		w.mu.Lock()
+		for !w.closed && (len(w.queue) == 0 || w.ahead >= w.maxAhead) {
+			w.cond.Wait()
+		}
+		if len(w.queue) == 0 {
+			w.mu.Unlock()
+			return
+		}
+		r := w.queue[0]
+		w.queue = w.queue[1:]
+		r.taken, r.ahead = true, true
+		w.ahead++
+		w.mu.Unlock()
+		w.readDir(r)
+	}
+}
+
+// readDir reads the directory r and marks it done. Once the listing is
+// cancelled, it is marked done without being read.
+func (w *walker) readDir(r *dirRead) {
+	defer close(r.done)
+	if w.ctx.Err() != nil {
+		return
+	}
+	// The token of the pool is shared with the goroutines that gather the
+	// details of the files of the directory.
+	pool := w.l.pool()
+	pool.acquire()
+	defer pool.release()
+	w.open <- struct{}{}
+	defer func() { <-w.open }()
+	if w.l.Flags.Recursive {
+		r.id, r.hasID = w.l.dirID(r.path)
+	}
+	r.entries, r.err = w.l.readDir(r.path)
+}
+
+// stop waits for the goroutines of the walker to finish the directories
+// they took, and ends them. Directories left in the queue, once the listing
+// is cancelled, are marked done without being read.
+func (w *walker) stop() {
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	w.cond.Broadcast()
+	w.wg.Wait()
+}
@@ -0,0 +1,278 @@
+package lsfunctions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// cancelWriter cancels a listing as soon as something is written.
+type cancelWriter struct {
+	bytes.Buffer
+	cancel context.CancelFunc
+}
+
+func (w *cancelWriter) Write(p []byte) (int, error) {
+	w.cancel()
+	return w.Buffer.Write(p)
+}
+
+// countingFS is an in-memory file system that keeps count of the
+// directories read, and of the most it was reading at once.
+type countingFS struct {
+	fstest.MapFS
+	// overlap, when set, holds every read after the first back until that
+	// many are in flight, so that reads that can run at once do. Should
+	// they not, the reads go on after a second.
+	overlap int
+	met     chan struct{}
+	metOnce sync.Once
+
+	mu            sync.Mutex
+	reads, active int
+	most          int
+}
+
+func (f *countingFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	f.mu.Lock()
+	f.reads++
+	first := f.reads == 1
+	f.active++
+	f.most = max(f.most, f.active)
+	if f.overlap > 0 && f.active >= f.overlap {
+		f.metOnce.Do(func() { close(f.met) })
+	}
+	f.mu.Unlock()
+	if f.overlap > 0 && !first {
+		select {
+		case <-f.met:
+		case <-time.After(time.Second):
+			f.metOnce.Do(func() { close(f.met) })
+		}
+	}
+	// Give the other reads the time to overlap with this one.
+	time.Sleep(time.Millisecond)
+	defer func() {
+		f.mu.Lock()
+		f.active--
+		f.mu.Unlock()
+	}()
+	return f.MapFS.ReadDir(name)
+}
+
+// wideFS returns a directory holding width subdirectories of one file each.
+func wideFS(width int) *countingFS {
+	fsys := fstest.MapFS{}
+	for i := 0; i < width; i++ {
+		fsys[fmt.Sprintf("tree/d%02d/file", i)] = &fstest.MapFile{Mode: 0o644}
+	}
+	return &countingFS{MapFS: fsys, met: make(chan struct{})}
+}
+
+// aheadWriter checks, each time a directory is printed, how many
+// directories were read ahead of it.
+type aheadWriter struct {
+	bytes.Buffer
+	fsys    *countingFS
+	printed int
+	most    int
+}
+
+func (w *aheadWriter) Write(p []byte) (int, error) {
+	if bytes.HasSuffix(p, []byte(":\n")) {
+		w.printed++
+		w.fsys.mu.Lock()
+		w.most = max(w.most, w.fsys.reads-w.printed)
+		w.fsys.mu.Unlock()
+		// Leave the walker the time to read ahead.
+		time.Sleep(2 * time.Millisecond)
+	}
+	return w.Buffer.Write(p)
+}
+
+// makeTree creates width directories of depth levels under dir, each
+// holding a file, and returns the listing ls -R gives of it.
+func makeTree(t *testing.T, dir string, width, depth int) string {
+	t.Helper()
+	var b strings.Builder
+	var walk func(path string, level int)
+	walk = func(path string, level int) {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s:\n", path)
+		var names []string
+		if level < depth {
+			for i := 0; i < width; i++ {
+				names = append(names, fmt.Sprintf("d%d", i))
+			}
+		}
+		names = append(names, "file")
+		for _, name := range names {
+			b.WriteString(name + "\n")
+		}
+		if level < depth {
+			for _, name := range names[:width] {
+				walk(path+"/"+name, level+1)
+			}
+		}
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(path, "file"), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	walk(dir, 0)
+	return b.String()
+}
+
+func TestLister_List_recursiveOrder(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tree")
+	want := makeTree(t, dir, 4, 3)
+	tests := []struct {
+		name  string
+		flags Flags
+	}{
+		{name: "test 1", flags: Flags{OnePerLine: true, Recursive: true, Jobs: 1}},
+		{name: "test 2", flags: Flags{OnePerLine: true, Recursive: true, Jobs: 8}},
+		{name: "test 3", flags: Flags{OnePerLine: true, Recursive: true, Jobs: 8, MaxOpenFiles: 1}},
+		{name: "test 4", flags: Flags{OnePerLine: true, Recursive: true, Jobs: 64, MaxOpenFiles: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout := &bytes.Buffer{}
+			l := &Lister{Flags: tt.flags, Stdout: stdout, Stderr: &bytes.Buffer{}, Resolver: fakeResolver{}}
+			if err := l.List([]string{dir}); err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if got := stdout.String(); got != want {
+				t.Errorf("List() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestLister_List_readAhead(t *testing.T) {
+	tests := []struct {
+		name string
+		jobs int
+	}{
+		{name: "test 1", jobs: 1},
+		{name: "test 2", jobs: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := wideFS(40)
+			if tt.jobs > 1 {
+				fsys.overlap = 2
+			}
+			stdout := &aheadWriter{fsys: fsys}
+			l := &Lister{Flags: Flags{OnePerLine: true, Recursive: true, Jobs: tt.jobs}, Stdout: stdout, Stderr: &bytes.Buffer{}, FS: fsys}
+			if err := l.List([]string{"tree"}); err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if got := strings.Count(stdout.String(), ":\n"); got != 41 {
+				t.Fatalf("List() printed %d directories, want 41", got)
+			}
+			if want := 2 * tt.jobs; stdout.most > want {
+				t.Errorf("List() read %d directories ahead, want at most %d", stdout.most, want)
+			}
+			if tt.jobs > 1 && fsys.most < 2 {
+				t.Errorf("List() read %d directory at once, want several", fsys.most)
+			}
+		})
+	}
+}
+
+func TestLister_List_maxOpenFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags Flags
+		want  int
+	}{
+		{name: "test 1", flags: Flags{OnePerLine: true, Recursive: true, Jobs: 8, MaxOpenFiles: 1}, want: 1},
+		{name: "test 2", flags: Flags{OnePerLine: true, Recursive: true, Jobs: 8, MaxOpenFiles: 3}, want: 3},
+		{name: "test 3", flags: Flags{OnePerLine: true, Recursive: true, Jobs: 2, MaxOpenFiles: 8}, want: 2},
+		{name: "test 4", flags: Flags{OnePerLine: true, Recursive: true, Jobs: 4}, want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := wideFS(40)
+			if tt.want > 1 {
+				fsys.overlap = 2
+			}
+			l := &Lister{Flags: tt.flags, Stdout: &bytes.Buffer{}, Stderr: &bytes.Buffer{}, FS: fsys}
+			if err := l.List([]string{"tree"}); err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if fsys.reads != 41 {
+				t.Errorf("List() read %d directories, want 41", fsys.reads)
+			}
+			if fsys.most > tt.want {
+				t.Errorf("List() read %d directories at once, want at most %d", fsys.most, tt.want)
+			}
+			if tt.want > 1 && fsys.most < 2 {
+				t.Errorf("List() read %d directory at once, want several", fsys.most)
+			}
+		})
+	}
+}
+
+func TestLister_ListContext(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "tree")
+	makeTree(t, dir, 3, 2)
+	t.Run("test 1", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		stdout := &bytes.Buffer{}
+		l := &Lister{Flags: Flags{OnePerLine: true, Recursive: true}, Stdout: stdout, Stderr: &bytes.Buffer{}, Resolver: fakeResolver{}}
+		err := l.ListContext(ctx, []string{dir})
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("ListContext() error = %v, want %v", err, context.Canceled)
+		}
+		if got := ExitStatus(err); got != 130 {
+			t.Errorf("ExitStatus(ListContext()) = %v, want 130", got)
+		}
+		if got := stdout.String(); got != "" {
+			t.Errorf("ListContext() = %q, want nothing", got)
+		}
+	})
+	t.Run("test 2", func(t *testing.T) {
+		// The directory being written when the listing is cancelled is
+		// finished, and nothing is written after it.
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stdout := &cancelWriter{cancel: cancel}
+		l := &Lister{Flags: Flags{OnePerLine: true, Recursive: true, Jobs: 4}, Stdout: stdout, Stderr: &bytes.Buffer{}, Resolver: fakeResolver{}}
+		err := l.ListContext(ctx, []string{dir})
+		if got := ExitStatus(err); got != 130 {
+			t.Errorf("ExitStatus(ListContext()) = %v, want 130", got)
+		}
+		if got, want := stdout.String(), dir+":\nd0\nd1\nd2\nfile\n"; got != want {
+			t.Errorf("ListContext() = %q, want %q", got, want)
+		}
+	})
+	t.Run("test 3", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		stdout := &cancelWriter{cancel: cancel}
+		l := &Lister{Flags: Flags{NDJSON: true, Recursive: true}, Stdout: stdout, Stderr: &bytes.Buffer{}, Resolver: fakeResolver{}}
+		err := l.ListContext(ctx, []string{dir})
+		if got := ExitStatus(err); got != 130 {
+			t.Errorf("ExitStatus(ListContext()) = %v, want 130", got)
+		}
+		if got := strings.Count(stdout.String(), "\n"); got != 4 {
+			t.Errorf("ListContext() wrote %d records, want the 4 of the first directory", got)
+		}
+	})
+}
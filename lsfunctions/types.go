@@ -1,34 +1,57 @@
 package lsfunctions
 
-import "os"
+import (
+	"os"
+	"time"
+)
 
 // FileDetails struct to store file information from readDir function
 type FileDetails struct {
-	Path       string
-	Name       string
-	Info       os.FileInfo
-	LinkTarget string
+	Path         string
+	Name         string
+	Info         os.FileInfo
+	LinkTarget   string
 	IsBrokenLink bool
-	Rdev       uint64
-	TargetInfo TargetInfo
+	Rdev         uint64
+	TargetInfo   TargetInfo
+	// BirthTime is the creation time of the file, filled in when it is
+	// shown or sorted on. It is zero when the file system does not record it.
+	BirthTime time.Time
 }
 
 type Entry struct {
 	Name, Mode, User, Owner, Group, Type,
-	LinkTarget, LinkCount, Size, Minor, Time, Path string
+	LinkTarget, LinkCount, Size, Major, Minor, Time, Path string
 	IsDirectory, IsBrokenLink bool
-	TargetInfo TargetInfo
+	TargetInfo                TargetInfo
+	// NumericOwner and NumericGroup tell that Owner and Group are numeric
+	// IDs, which are aligned to the right.
+	NumericOwner, NumericGroup bool
+	// Inode and Blocks are the inode number and the allocated size,
+	// only filled in when they are shown.
+	Inode, Blocks string
+	// RawName is the name as the file system holds it, before quoting.
+	// The colour of a name is chosen from it.
+	RawName string
+	// Indicator is the character appended to the name to tell its type.
+	// The long format leaves it out for a symbolic link, writing the one of
+	// its target after the arrow instead.
+	Indicator string
 }
 
+// TargetInfo describes the file a symbolic link leads to. Name is the target
+// as the link holds it and Path the same resolved against the link's
+// directory. Mode and Type, the LS_COLORS key of the kind of file, are those
+// of the file at the end of the chain of links; they are empty for a broken
+// link.
 type TargetInfo struct {
-	Name, Mode string
-	IsBrokenLink bool
+	Name, Path, Mode, Type string
+	IsBrokenLink           bool
 }
 
 type TotalBlocks int64
 
-var ShowTotals bool
-
 type Widths struct {
 	sizeCol, ownerCol, groupCol, linkCol, timeCol, modCol, minorCol int
+	inodeCol, blocksCol, majorCol                                   int
 }
@@ -1,41 +1,18 @@
 package lsfunctions
 
 import (
-	"fmt"
+	"path"
 	"strings"
-	"syscall"
-	"time"
 )
 
-// This function resolves relative paths against a link path.
-// It follows the rules of resolving relative paths in a file system.
-// It returns the resolved path.
-func resolveRelativePath(linkPath, target string) string {
-	linkDir := ""
-	lastSlash := strings.LastIndex(linkPath, "/")
-	if lastSlash != -1 {
-		linkDir = linkPath[:lastSlash]
-	}
-	// Return path as is if it's already absolute
+// resolveLinkTarget returns the path of the target of the symbolic link at
+// linkPath. A relative target is relative to the directory holding the link,
+// and the result stays relative when linkPath is.
+func resolveLinkTarget(linkPath, target string) string {
 	if strings.HasPrefix(target, "/") {
-		return target
-	}
-	joinedPath := joinPath(linkDir, target)
-
-	// Normalize combined path
-	segments := strings.Split(joinedPath, "/")
-	var stack []string
-
-	for _, segment := range segments {
-		if segment == ".." {
-			if len(stack) > 0 {
-				stack = stack[:len(stack)-1]
-			}
-		} else if segment != "." && segment != "" {
-			stack = append(stack, segment)
-		}
+		return path.Clean(target)
 	}
-	return "/" + strings.Join(stack, "/")
+	return path.Join(path.Dir(linkPath), target)
 }
 
 // Clean string to remove -, _, and. from the name.
@@ -79,6 +56,16 @@ func setEntryPath(baseDir string, entry *FileDetails) {
 	}
 }
 
+// reportPath returns the path a problem with the file name of the directory
+// dir is reported under. As in GNU ls, it keeps the spelling of dir, and a
+// file of the current directory, ".", is named alone.
+func reportPath(dir, name string) string {
+	if dir == "." {
+		return name
+	}
+	return joinPath(dir, name)
+}
+
 // joinPath joins two paths into a single path.
 func joinPath(dir, file string) string {
 	dir = strings.TrimSuffix(dir, "/")
@@ -107,56 +94,35 @@ func getMax(a, b int) int {
 	return b
 }
 
-// formatTime formats a given time based on whether it's in the current year or not.
-// For times in the current year, it returns the format "Jan _2 15:04".
-// For times in previous years, it returns the format "Jan _2 2006".
-func formatTime(modTime time.Time) string {
-	now := time.Now()
-	if modTime.Year() == now.Year() {
-		return modTime.Format("Jan _2 15:04")
-	}
-	return modTime.Format("Jan _2  2006")
-}
-
 // getTotalBlocks calculates the total number of 512-byte blocks in the filesystem.
 func getTotalBlocks(entries []FileDetails) TotalBlocks {
 	var t TotalBlocks
 	for _, entry := range entries {
-		if stat, ok := entry.Info.Sys().(*syscall.Stat_t); ok {
+		if stat, ok := fileStat(entry.Info); ok {
 			t += TotalBlocks(stat.Blocks)
 		}
 	}
-	return t / 2
-}
-
-// addQuotes adds quotes to the input string if it contains spaces or special characters.
-func addQuotes(s string) string {
-	if strings.Contains(s, " ") || hasSpecialChar(s) {
-		s = fmt.Sprintf(`'%s'`, s)
-	}
-	return s
-}
-
-// hasSpecialChar checks if the input string contains any special characters.
-func hasSpecialChar(s string) bool {
-	if len(s) == 0 {
-		return false
-	}
-	specialChars := []rune{'[', '#', ']', '{', '}', '|', '\\', ':', ';', '<', '>', ',', '?', '!', '@', '$', '%', '^', '&', '*', '(', ')', '~', '`', '"', '\'', '=', '+'}
-	for _, ch := range specialChars {
-		if strings.ContainsRune(s, ch) {
-			return true
-		}
-	}
-	return false
+	return t
 }
 
 // major returns the major device number of the given device.
+// Device numbers use the 64-bit encoding of glibc's gnu_dev_major, which
+// keeps the 8-bit layout of old kernels in the low 16 bits and spreads the
+// larger 32-bit major and minor numbers of Linux around it:
+//
+//	bits 63-44: major 31-12   bits 43-20: minor 31-8
+//	bits 19-8:  major 11-0    bits 7-0:   minor 7-0
 func major(dev uint64) uint64 {
-	return (dev >> 8) & 0xff
+	return (dev>>32)&0xfffff000 | (dev>>8)&0x00000fff
 }
 
 // minor returns the minor device number of the given device.
 func minor(dev uint64) uint64 {
-	return dev & 0xff
+	return (dev>>12)&0xffffff00 | dev&0x000000ff
+}
+
+// makedev combines major and minor device numbers into a device number.
+func makedev(major, minor uint64) uint64 {
+	return (major&0x00000fff)<<8 | (major&0xfffff000)<<32 |
+		minor&0x000000ff | (minor&0xffffff00)<<12
 }
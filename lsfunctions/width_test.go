@@ -0,0 +1,92 @@
+package lsfunctions
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func Test_displayWidth(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want int
+	}{
+		{name: "ascii", s: "readme.md", want: 9},
+		{name: "japanese", s: "画像.png", want: 8},
+		{name: "katakana and hiragana", s: "アセットの", want: 10},
+		{name: "precomposed accent", s: "café", want: 4},
+		{name: "combining accent", s: "café", want: 4},
+		{name: "hangul", s: "한국어", want: 6},
+		{name: "fullwidth letters", s: "ＡＢ", want: 4},
+		{name: "emoji", s: "🎵.mp3", want: 6},
+		{name: "zero width joiner", s: "👩‍💻", want: 4},
+		{name: "escape sequences", s: "\033[1;34m画像\033[0m", want: 4},
+		{name: "invalid utf-8", s: "a\xffb", want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := displayWidth(tt.s); got != tt.want {
+				t.Errorf("displayWidth(%q) = %v, want %v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_padRight(t *testing.T) {
+	tests := []struct {
+		name  string
+		s     string
+		width int
+		want  string
+	}{
+		{name: "test 1", s: "ab", width: 4, want: "ab  "},
+		{name: "test 2", s: "画像", width: 6, want: "画像  "},
+		{name: "test 3", s: "é", width: 2, want: "é "},
+		{name: "test 4", s: "画像", width: 3, want: "画像"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := padRight(tt.s, tt.width); got != tt.want {
+				t.Errorf("padRight() = %q, want %q", got, tt.want)
+			}
+			if got := displayWidth(padLeft(tt.s, tt.width)); got != displayWidth(tt.want) {
+				t.Errorf("padLeft() width = %v, want %v", got, displayWidth(tt.want))
+			}
+		})
+	}
+}
+
+func TestLister_displayShortList_wide(t *testing.T) {
+	entries := []FileDetails{
+		{Name: "画像.png", Path: "画像.png", Info: mockFileInfo{mode: 0o644}},
+		{Name: "café.txt", Path: "café.txt", Info: mockFileInfo{mode: 0o644}},
+		{Name: "b", Path: "b", Info: mockFileInfo{mode: 0o644}},
+		{Name: "アセット", Path: "アセット", Info: mockFileInfo{mode: 0o644}},
+	}
+	var w bytes.Buffer
+	l := &Lister{Flags: Flags{Columns: true, Width: 24, Color: ColorNever}, Stdout: &w, Resolver: fakeResolver{}}
+	l.displayShortList(entries)
+	want := "画像.png  b\ncafé.txt  アセット\n"
+	if got := w.String(); got != want {
+		t.Errorf("displayShortList() = %q, want %q", got, want)
+	}
+}
+
+func TestLister_getLongFormatString_wide(t *testing.T) {
+	l := &Lister{Flags: Flags{Color: ColorNever}, Stdout: os.Stdout}
+	entries := []Entry{
+		{Name: "a", Mode: "-rw-r--r--", LinkCount: "1", Owner: "山田", Group: "staff", Size: "1", Time: "Jan  1 00:00"},
+		{Name: "b", Mode: "-rw-r--r--", LinkCount: "1", Owner: "root", Group: "グループ", Size: "10", Time: "Jan  1 00:00"},
+	}
+	w := getWidths(entries)
+	want := []string{
+		"-rw-r--r-- 1 山田 staff     1 Jan  1 00:00 a",
+		"-rw-r--r-- 1 root グループ 10 Jan  1 00:00 b",
+	}
+	for i, e := range entries {
+		if got := l.getLongFormatString(e, w); got != want[i] {
+			t.Errorf("getLongFormatString() = %q, want %q", got, want[i])
+		}
+	}
+}
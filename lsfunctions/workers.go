@@ -0,0 +1,72 @@
+package lsfunctions
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// jobs returns the number of goroutines the Lister reads directories and
+// gathers the details of their files with: the Jobs flag, or one per CPU
+// by default.
+func (l *Lister) jobs() int {
+	if l.Flags.Jobs > 0 {
+		return l.Flags.Jobs
+	}
+	return runtime.NumCPU()
+}
+
+// pool returns the pool shared by all the work of the Lister, so that the
+// directories read at once and the files of each one described at once
+// take no more than jobs goroutines between them.
+func (l *Lister) pool() pool {
+	l.poolOnce.Do(func() {
+		l.workers = make(pool, l.jobs())
+	})
+	return l.workers
+}
+
+// pool bounds the number of goroutines working on a listing. Each one holds
+// a token of the pool while it works.
+type pool chan struct{}
+
+// acquire waits for a token of the pool.
+func (p pool) acquire() { p <- struct{}{} }
+
+// release gives back a token taken with acquire.
+func (p pool) release() { <-p }
+
+// forEach calls fn with every index below n and returns once all the calls
+// are done. They run on the calling goroutine and on as many more as the
+// pool has free tokens for, without waiting for any. Each call must only
+// write to what belongs to its index, such as its element of a slice, so
+// that results end up in the same order whatever the order the calls ran in.
+func (p pool) forEach(n int, fn func(i int)) {
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	run := func() {
+		for {
+			i := int(next.Add(1) - 1)
+			if i >= n {
+				return
+			}
+			fn(i)
+		}
+	}
+start:
+	for helpers := 1; helpers < n; helpers++ {
+		select {
+		case p <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer p.release()
+				run()
+			}()
+		default:
+			break start
+		}
+	}
+	run()
+	wg.Wait()
+}
@@ -0,0 +1,88 @@
+package lsfunctions
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_pool_forEach(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		size int
+		held int
+		want int
+	}{
+		{name: "test 1", n: 0, size: 4, want: 1},
+		{name: "test 2", n: 1, size: 4, want: 1},
+		{name: "test 3", n: 100, size: 0, want: 1},
+		{name: "test 4", n: 100, size: 3, want: 4},
+		{name: "test 5", n: 3, size: 16, want: 3},
+		{name: "test 6", n: 100, size: 4, held: 3, want: 2},
+		{name: "test 7", n: 100, size: 4, held: 4, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := make(pool, tt.size)
+			for i := 0; i < tt.held; i++ {
+				p.acquire()
+			}
+			calls := make([]int, tt.n)
+			var running, most atomic.Int64
+			var mu sync.Mutex
+			p.forEach(tt.n, func(i int) {
+				now := running.Add(1)
+				mu.Lock()
+				most.Store(max(most.Load(), now))
+				calls[i]++
+				mu.Unlock()
+				running.Add(-1)
+			})
+			for i, c := range calls {
+				if c != 1 {
+					t.Errorf("forEach() called fn(%d) %d times, want 1", i, c)
+				}
+			}
+			if got := int(most.Load()); got > tt.want {
+				t.Errorf("forEach() ran %d calls at once, want at most %d", got, tt.want)
+			}
+			if got := len(p); got != tt.held {
+				t.Errorf("forEach() left %d tokens taken, want %d", got, tt.held)
+			}
+		})
+	}
+}
+
+func TestLister_List_jobs(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 200; i++ {
+		name := filepath.Join(dir, fmt.Sprintf("file%03d", i))
+		if err := os.WriteFile(name, bytes.Repeat([]byte("x"), i), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if i%10 == 0 {
+			if err := os.Symlink(name, name+".link"); err != nil {
+				t.Skip("symbolic links not supported:", err)
+			}
+		}
+	}
+	list := func(jobs int) string {
+		stdout := &bytes.Buffer{}
+		l := &Lister{Flags: Flags{Long: true, Sort: SortNone, Jobs: jobs}, Stdout: stdout, Stderr: &bytes.Buffer{}, Resolver: NewCachingResolver(partialResolver{})}
+		if err := l.List([]string{dir}); err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		return stdout.String()
+	}
+	want := list(1)
+	for _, jobs := range []int{2, 8, 64} {
+		if got := list(jobs); got != want {
+			t.Errorf("List() with %d jobs = %q, want %q", jobs, got, want)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 
 	ls "my-ls/lsfunctions"
 )
@@ -12,46 +14,33 @@ func main() {
 	flags, paths, err := ls.ParseFlags(os.Args[1:])
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "ls: %v\n", err)
-		return
+		os.Exit(2)
 	}
-	if len(paths) == 0 {
-		paths = []string{"."}
+	switch {
+	case flags.Help:
+		ls.PrintUsage(os.Stdout)
+		return
+	case flags.Version:
+		ls.PrintVersion(os.Stdout)
+		return
 	}
-	// Sort paths alphabetically and case-insensitively
-	paths, idx := ls.SortPaths(paths)
 
-	for i, path := range paths {
-		if flags.Recursive {
-			if flags.Long {
-				ls.ShowTotals = true
-			}
-			if i > 0 {
-				fmt.Println()
-			}
-			fmt.Printf("%s:\n", path)
-		} else if i >= idx {
-			if flags.Long {
-				ls.ShowTotals = true
-			}
-			if i > 0 {
-				fmt.Println()
-			}
-			if len(paths) > 1 {
-				fmt.Printf("%s:\n", path)
-			}
-			// if flags.Recursive || len(paths) >= 1 {
-			// 	if i > 0 {
-			// 		fmt.Println()
-			// 	}
-			// 	if len(paths) > 1 {
-			// 		fmt.Printf("%s:\n", path)
-			// 	}
-			// }
-			// ls.ShowTotals = true
-		}
-		err := ls.ListPath(path, flags)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "ls: cannot access '%s': No such file or directory\n", path)
+	l := ls.NewLister(flags)
+	l.Colors, err = ls.LoadColors(flags.ColorsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ls: %v\n", err)
+		if l.Colors == nil {
+			os.Exit(2)
 		}
 	}
+	if flags.PrintColors {
+		l.PrintColors()
+		return
+	}
+	// Ctrl-C stops the listing after the directory being written, with
+	// the status of an interrupted program.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err = l.ListContext(ctx, paths)
+	stop()
+	os.Exit(ls.ExitStatus(err))
 }